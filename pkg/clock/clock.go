@@ -0,0 +1,223 @@
+// Package clock abstracts the time functions, so time dependent code can be tested with a fake clock.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock provides the current time and waits for durations
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	After(d time.Duration) <-chan time.Time
+	Sleep(d time.Duration)
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer a single event timer, as time.Timer
+type Timer interface {
+	C() <-chan time.Time
+	// Stop prevents the timer from firing, it returns false if the timer already fired or was stopped
+	Stop() bool
+	// Reset changes the timer to fire after d, it must be stopped or fired and drained
+	Reset(d time.Duration) bool
+}
+
+type realClock struct{}
+
+// Real is the clock of the system
+var Real Clock = realClock{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Since(t time.Time) time.Duration {
+	return time.Since(t)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	*time.Timer
+}
+
+func (rt realTimer) C() <-chan time.Time {
+	return rt.Timer.C
+}
+
+// OrReal returns the clock, or the real clock if it is nil
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+type fakeWaiter struct {
+	until time.Time
+	ch    chan time.Time
+}
+
+// FakeClock a clock whose time only moves when Advance or Set is called
+type FakeClock struct {
+	now     time.Time
+	waiters []*fakeWaiter
+	cond    *sync.Cond
+	mu      sync.Mutex
+}
+
+var _ Clock = &FakeClock{}
+
+// NewFakeClock creates a fake clock starting at now, or at a fixed date if now is zero
+func NewFakeClock(now time.Time) *FakeClock {
+	if now.IsZero() {
+		now = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	fc := &FakeClock{now: now}
+	fc.cond = sync.NewCond(&fc.mu)
+	return fc
+}
+
+func (fc *FakeClock) Now() time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return fc.now
+}
+
+func (fc *FakeClock) Since(t time.Time) time.Duration {
+	return fc.Now().Sub(t)
+}
+
+// After returns a channel receiving the time once the clock is advanced by d
+func (fc *FakeClock) After(d time.Duration) <-chan time.Time {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	fc.wait(&fakeWaiter{until: fc.now.Add(d), ch: ch})
+	return ch
+}
+
+func (fc *FakeClock) wait(waiter *fakeWaiter) {
+	if !waiter.until.After(fc.now) {
+		fire(waiter, fc.now)
+		return
+	}
+	fc.waiters = append(fc.waiters, waiter)
+	fc.cond.Broadcast()
+}
+
+// fire sends the time unless the channel is full, as a time.Timer
+func fire(waiter *fakeWaiter, t time.Time) {
+	select {
+	case waiter.ch <- t:
+	default:
+	}
+}
+
+// NewTimer returns a timer firing once the clock is advanced by d
+func (fc *FakeClock) NewTimer(d time.Duration) Timer {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	ft := &fakeTimer{fc: fc, waiter: &fakeWaiter{until: fc.now.Add(d), ch: make(chan time.Time, 1)}}
+	fc.wait(ft.waiter)
+	return ft
+}
+
+type fakeTimer struct {
+	fc     *FakeClock
+	waiter *fakeWaiter
+}
+
+func (ft *fakeTimer) C() <-chan time.Time {
+	return ft.waiter.ch
+}
+
+func (ft *fakeTimer) Stop() bool {
+	ft.fc.mu.Lock()
+	defer ft.fc.mu.Unlock()
+	return ft.fc.remove(ft.waiter)
+}
+
+func (ft *fakeTimer) Reset(d time.Duration) bool {
+	ft.fc.mu.Lock()
+	defer ft.fc.mu.Unlock()
+	active := ft.fc.remove(ft.waiter)
+	ft.waiter.until = ft.fc.now.Add(d)
+	ft.fc.wait(ft.waiter)
+	return active
+}
+
+func (fc *FakeClock) remove(waiter *fakeWaiter) bool {
+	for i, w := range fc.waiters {
+		if w == waiter {
+			fc.waiters = append(fc.waiters[:i], fc.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Sleep blocks until the clock is advanced by d
+func (fc *FakeClock) Sleep(d time.Duration) {
+	<-fc.After(d)
+}
+
+// Advance moves the clock forward and fires the waiters which are due
+func (fc *FakeClock) Advance(d time.Duration) {
+	fc.mu.Lock()
+	fc.set(fc.now.Add(d))
+	fc.mu.Unlock()
+}
+
+// Set moves the clock to the time and fires the waiters which are due
+func (fc *FakeClock) Set(t time.Time) {
+	fc.mu.Lock()
+	fc.set(t)
+	fc.mu.Unlock()
+}
+
+func (fc *FakeClock) set(t time.Time) {
+	fc.now = t
+	sort.SliceStable(fc.waiters, func(i, j int) bool {
+		return fc.waiters[i].until.Before(fc.waiters[j].until)
+	})
+	fired := 0
+	for _, waiter := range fc.waiters {
+		if waiter.until.After(t) {
+			break
+		}
+		fire(waiter, t)
+		fired++
+	}
+	fc.waiters = fc.waiters[fired:]
+	fc.cond.Broadcast()
+}
+
+// Waiters returns the number of pending After and Sleep calls and timers
+func (fc *FakeClock) Waiters() int {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	return len(fc.waiters)
+}
+
+// BlockUntil blocks until there are at least n pending After and Sleep calls and timers
+func (fc *FakeClock) BlockUntil(n int) {
+	fc.mu.Lock()
+	defer fc.mu.Unlock()
+	for len(fc.waiters) < n {
+		fc.cond.Wait()
+	}
+}
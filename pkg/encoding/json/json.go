@@ -16,3 +16,55 @@
  */
 
 package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path"
+
+	perrors "github.com/pkg/errors"
+)
+
+// RawMessage is a raw encoded JSON value, it can be used to delay decoding
+type RawMessage = json.RawMessage
+
+// LoadJSONConfig Load json config byte from file
+func LoadJSONConfig(confFile string) ([]byte, error) {
+	if len(confFile) == 0 {
+		return nil, perrors.Errorf("application configure file name is nil")
+	}
+
+	if path.Ext(confFile) != ".json" {
+		return nil, perrors.Errorf("application configure file name{%v} suffix must be .json", confFile)
+	}
+
+	return os.ReadFile(confFile)
+}
+
+// UnmarshalJSONConfig Load json config byte from file, then unmarshal to object
+func UnmarshalJSONConfig(confFile string, out interface{}) ([]byte, error) {
+	confFileStream, err := LoadJSONConfig(confFile)
+	if err != nil {
+		return confFileStream, perrors.Errorf("os.ReadFile(file:%s) = error:%v", confFile, perrors.WithStack(err))
+	}
+	return confFileStream, json.Unmarshal(confFileStream, out)
+}
+
+// UnmarshalJSON parses the JSON-encoded data and stores the result in the value pointed to by out.
+func UnmarshalJSON(data []byte, out interface{}) error {
+	return json.Unmarshal(data, out)
+}
+
+// UnmarshalJSONNumber is like UnmarshalJSON, but numbers decoded into interface{} values are kept as json.Number,
+// so integers survive a decode and re-encode round trip without losing precision.
+func UnmarshalJSONNumber(data []byte, out interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(out)
+}
+
+// MarshalJSON serializes the value provided into a JSON document.
+func MarshalJSON(in interface{}) ([]byte, error) {
+	return json.Marshal(in)
+}
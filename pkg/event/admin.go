@@ -0,0 +1,153 @@
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// GroupInfo topology of a publisher group
+type GroupInfo struct {
+	Name          string          `json:"name"`
+	Dispatcher    string          `json:"dispatcher"`
+	Started       bool            `json:"started"`
+	Paused        bool            `json:"paused"`
+	QueueLength   int             `json:"queueLength"`
+	QueueCapacity int             `json:"queueCapacity"`
+	DeadLetters   int             `json:"deadLetters"`
+	Publishers    []PublisherInfo `json:"publishers"`
+}
+
+// PublisherInfo topology of a publisher
+type PublisherInfo struct {
+	Name         string         `json:"name"`
+	Handlers     int            `json:"handlers"`
+	HandlerTypes map[string]int `json:"handlerTypes"`
+}
+
+// DeadLetterInfo a drained dead letter, the event is rendered as text
+type DeadLetterInfo struct {
+	Publisher string    `json:"publisher"`
+	Event     string    `json:"event"`
+	Reason    string    `json:"reason"`
+	Time      time.Time `json:"time"`
+}
+
+// AdminHandler exposes the topology of a GoEventBus over HTTP:
+//
+//	GET  /groups                     topology of all groups
+//	GET  /groups/{group}             topology of a group
+//	POST /groups/{group}/pause       pause the dispatcher of a group
+//	POST /groups/{group}/resume      resume the dispatcher of a group
+//	POST /groups/{group}/deadletters drain the dead letters of a group
+//
+// Mount it with http.StripPrefix when serving under a sub path.
+type AdminHandler[T Event] struct {
+	bus *GoEventBus[T]
+}
+
+var _ http.Handler = &AdminHandler[AbstractEvent]{}
+
+func NewAdminHandler[T Event](bus *GoEventBus[T]) *AdminHandler[T] {
+	return &AdminHandler[T]{bus: bus}
+}
+
+func (ah *AdminHandler[T]) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) == 1 && (parts[0] == "" || parts[0] == "groups") {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, r.Method+" not allowed")
+			return
+		}
+		groups := make([]GroupInfo, 0)
+		for _, name := range ah.bus.GroupNames() {
+			if group := ah.bus.Group(name); group != nil {
+				groups = append(groups, groupInfo(name, group))
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"groups": groups})
+		return
+	}
+	if len(parts) < 2 || len(parts) > 3 || parts[0] != "groups" {
+		writeError(w, http.StatusNotFound, "unknown path "+r.URL.Path)
+		return
+	}
+	name := parts[1]
+	group := ah.bus.Group(name)
+	if group == nil {
+		writeError(w, http.StatusNotFound, "unknown group "+name)
+		return
+	}
+	if len(parts) == 2 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, r.Method+" not allowed")
+			return
+		}
+		writeJSON(w, http.StatusOK, groupInfo(name, group))
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, r.Method+" not allowed")
+		return
+	}
+	switch parts[2] {
+	case "pause":
+		group.Dispatcher().Pause()
+		writeJSON(w, http.StatusOK, groupInfo(name, group))
+	case "resume":
+		group.Dispatcher().Resume()
+		writeJSON(w, http.StatusOK, groupInfo(name, group))
+	case "deadletters":
+		letters := group.DrainDeadLetters()
+		infos := make([]DeadLetterInfo, len(letters))
+		for i, letter := range letters {
+			infos[i] = DeadLetterInfo{
+				Publisher: letter.Publisher,
+				Event:     fmt.Sprintf("%+v", letter.Event),
+				Reason:    letter.Reason,
+				Time:      letter.Time,
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"group": name, "drained": len(infos), "deadLetters": infos})
+	default:
+		writeError(w, http.StatusNotFound, "unknown action "+parts[2])
+	}
+}
+
+func groupInfo[T Event](name string, group *PublisherGroup[T]) GroupInfo {
+	dispatcher := group.Dispatcher()
+	info := GroupInfo{
+		Name:          name,
+		Dispatcher:    dispatcher.Name(),
+		Started:       dispatcher.IsStarted(),
+		Paused:        dispatcher.IsPaused(),
+		QueueLength:   dispatcher.Len(),
+		QueueCapacity: dispatcher.Cap(),
+		DeadLetters:   group.DeadLetters(),
+		Publishers:    make([]PublisherInfo, 0),
+	}
+	for _, publisher := range group.Publishers() {
+		info.Publishers = append(info.Publishers, PublisherInfo{
+			Name:         publisher.Name,
+			Handlers:     publisher.Size(),
+			HandlerTypes: publisher.HandlerTypes(),
+		})
+	}
+	sort.Slice(info.Publishers, func(i, j int) bool {
+		return info.Publishers[i].Name < info.Publishers[j].Name
+	})
+	return info
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
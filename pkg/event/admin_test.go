@@ -0,0 +1,69 @@
+package event
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAdminHandler(t *testing.T) {
+	bus := NewGoEventBus[TestEvent]()
+	publisher := bus.GetPublisher("event.common", "default").(*GoPublisher[TestEvent])
+	publisher.AddHandler(&TestEventHandler{})
+	handler := NewAdminHandler(bus)
+
+	serve := func(method, path string, out interface{}) int {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(method, path, nil))
+		if out != nil {
+			if err := json.Unmarshal(recorder.Body.Bytes(), out); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return recorder.Code
+	}
+
+	info := GroupInfo{}
+	if code := serve(http.MethodPost, "/groups/event.common/pause", &info); code != http.StatusOK || !info.Paused {
+		t.Errorf("Got %v %v expected %v %v", code, info.Paused, http.StatusOK, true)
+	}
+	publisher.Offer(TestEvent{AbstractEvent{Source: "hello1"}})
+	publisher.Offer(TestEvent{AbstractEvent{Source: "hello2"}})
+
+	topology := struct {
+		Groups []GroupInfo `json:"groups"`
+	}{}
+	serve(http.MethodGet, "/groups", &topology)
+	if actualValue := len(topology.Groups); actualValue != 1 {
+		t.Fatalf("Got %v expected %v", actualValue, 1)
+	}
+	group := topology.Groups[0]
+	if group.QueueLength != 2 || group.QueueCapacity != 1024 || group.Started {
+		t.Errorf("Got %+v expected queue 2/1024 not started", group)
+	}
+	if actualValue := group.Publishers[0].HandlerTypes["*event.TestEventHandler"]; actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+
+	publisher.publish(TestEvent{AbstractEvent{Source: "lost", Target: "nobody"}})
+	drained := struct {
+		Drained     int              `json:"drained"`
+		DeadLetters []DeadLetterInfo `json:"deadLetters"`
+	}{}
+	serve(http.MethodPost, "/groups/event.common/deadletters", &drained)
+	if drained.Drained != 1 || drained.DeadLetters[0].Reason != ReasonNoTarget {
+		t.Errorf("Got %+v expected one %q dead letter", drained, ReasonNoTarget)
+	}
+	if actualValue := bus.Group("event.common").DeadLetters(); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+
+	serve(http.MethodPost, "/groups/event.common/resume", &info)
+	if info.Paused {
+		t.Errorf("Got %v expected %v", info.Paused, false)
+	}
+	if code := serve(http.MethodGet, "/groups/unknown", nil); code != http.StatusNotFound {
+		t.Errorf("Got %v expected %v", code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,259 @@
+package event
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+const (
+	// ReasonCircuitOpen the circuit breaker of the handler was open and no fallback was configured
+	ReasonCircuitOpen = "circuit open"
+	// ReasonHandlerError a fallible handler failed to handle the event
+	ReasonHandlerError = "handler error"
+)
+
+// CircuitState state of a circuit breaker
+type CircuitState int32
+
+const (
+	// CircuitClosed events are delivered to the handler
+	CircuitClosed CircuitState = iota
+	// CircuitOpen events skip the handler until the cool-down elapses
+	CircuitOpen
+	// CircuitHalfOpen a few trial events are delivered to decide whether the handler recovered
+	CircuitHalfOpen
+)
+
+func (cs CircuitState) String() string {
+	switch cs {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int32(cs))
+}
+
+// CircuitStateEvent is published when a circuit breaker changes state, its source is the guarded handler
+type CircuitStateEvent struct {
+	AbstractEvent
+	Group     string
+	Publisher string
+	From      CircuitState
+	To        CircuitState
+	Time      time.Time
+}
+
+// CircuitBreakerConfig Configure the circuit breakers of the handlers of a publisher
+type CircuitBreakerConfig struct {
+	// FailureRate opens the circuit when reached by the failed deliveries in the window, 0.5 by default
+	FailureRate float64
+	// MinRequests is the number of deliveries in the window before the failure rate is evaluated, 10 by default
+	MinRequests int
+	// Window is the number of latest deliveries the failure rate is computed on, 20 by default
+	Window int
+	// CoolDown is how long the circuit stays open before trial deliveries, 30s by default
+	CoolDown time.Duration
+	// HalfOpenRequests is the number of successful trial deliveries closing the circuit, 1 by default
+	HalfOpenRequests int
+	// Publisher receives the state transitions of the circuit breakers if set
+	Publisher Publisher[CircuitStateEvent]
+	Clock     clock.Clock
+}
+
+func (cbc *CircuitBreakerConfig) withDefaults() CircuitBreakerConfig {
+	config := *cbc
+	if config.FailureRate <= 0 || config.FailureRate > 1 {
+		config.FailureRate = 0.5
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = 10
+	}
+	if config.Window < config.MinRequests {
+		config.Window = config.MinRequests * 2
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = 30 * time.Second
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	config.Clock = clock.OrReal(config.Clock)
+	return config
+}
+
+type circuitBreaker struct {
+	config CircuitBreakerConfig
+	state  CircuitState
+	// results is a ring of the latest delivery outcomes in closed state, true means failed
+	results  []bool
+	next     int
+	count    int
+	failures int
+	openedAt time.Time
+	trials   int
+	passed   int
+	onChange func(from, to CircuitState)
+	mu       sync.Mutex
+}
+
+func newCircuitBreaker(config CircuitBreakerConfig, onChange func(from, to CircuitState)) *circuitBreaker {
+	return &circuitBreaker{
+		config:   config,
+		results:  make([]bool, config.Window),
+		onChange: onChange,
+	}
+}
+
+func (cb *circuitBreaker) State() CircuitState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
+}
+
+// allow reports whether the delivery may go through, the open circuit turns half-open after the cool-down
+func (cb *circuitBreaker) allow() bool {
+	cb.mu.Lock()
+	from := cb.state
+	if cb.state == CircuitOpen && cb.config.Clock.Since(cb.openedAt) >= cb.config.CoolDown {
+		cb.transit(CircuitHalfOpen)
+	}
+	allowed := cb.state == CircuitClosed
+	if cb.state == CircuitHalfOpen && cb.trials < cb.config.HalfOpenRequests {
+		cb.trials++
+		allowed = true
+	}
+	to := cb.state
+	cb.mu.Unlock()
+	cb.notify(from, to)
+	return allowed
+}
+
+// record accounts the outcome of an allowed delivery
+func (cb *circuitBreaker) record(failed bool) {
+	cb.mu.Lock()
+	from := cb.state
+	switch cb.state {
+	case CircuitClosed:
+		if cb.count == len(cb.results) {
+			if cb.results[cb.next] {
+				cb.failures--
+			}
+		} else {
+			cb.count++
+		}
+		cb.results[cb.next] = failed
+		cb.next = (cb.next + 1) % len(cb.results)
+		if failed {
+			cb.failures++
+		}
+		if cb.count >= cb.config.MinRequests && float64(cb.failures)/float64(cb.count) >= cb.config.FailureRate {
+			cb.transit(CircuitOpen)
+		}
+	case CircuitHalfOpen:
+		if failed {
+			cb.transit(CircuitOpen)
+		} else if cb.passed++; cb.passed >= cb.config.HalfOpenRequests {
+			cb.transit(CircuitClosed)
+		}
+	}
+	to := cb.state
+	cb.mu.Unlock()
+	cb.notify(from, to)
+}
+
+func (cb *circuitBreaker) transit(state CircuitState) {
+	cb.state = state
+	switch state {
+	case CircuitOpen:
+		cb.openedAt = cb.config.Clock.Now()
+	case CircuitHalfOpen:
+		cb.trials, cb.passed = 0, 0
+	case CircuitClosed:
+		cb.next, cb.count, cb.failures = 0, 0, 0
+	}
+}
+
+func (cb *circuitBreaker) notify(from, to CircuitState) {
+	if from != to && cb.onChange != nil {
+		cb.onChange(from, to)
+	}
+}
+
+// CircuitBreakerHandler guards a handler of a publisher with a circuit breaker. A handler fails when it
+// panics, or when it is a FallibleEventHandler returning an error.
+type CircuitBreakerHandler[T Event] struct {
+	handler   EventHandler[T]
+	publisher *GoPublisher[T]
+	fallback  EventHandler[T]
+	breaker   *circuitBreaker
+}
+
+func newCircuitBreakerHandler[T Event](publisher *GoPublisher[T], handler EventHandler[T], config CircuitBreakerConfig,
+	fallback EventHandler[T]) *CircuitBreakerHandler[T] {
+	cbh := &CircuitBreakerHandler[T]{
+		handler:   handler,
+		publisher: publisher,
+		fallback:  fallback,
+	}
+	cbh.breaker = newCircuitBreaker(config, func(from, to CircuitState) {
+		if config.Publisher != nil {
+			config.Publisher.Offer(CircuitStateEvent{
+				AbstractEvent: AbstractEvent{Source: handler},
+				Group:         publisher.Group.name,
+				Publisher:     publisher.Name,
+				From:          from,
+				To:            to,
+				Time:          config.Clock.Now(),
+			})
+		}
+	})
+	return cbh
+}
+
+func (cbh *CircuitBreakerHandler[T]) Handler(event T) {
+	if !cbh.breaker.allow() {
+		if cbh.fallback != nil {
+			cbh.fallback.Handler(event)
+		} else {
+			cbh.publisher.Group.addDeadLetter(cbh.publisher.Name, event, ReasonCircuitOpen)
+		}
+		return
+	}
+	err := cbh.invoke(event)
+	cbh.breaker.record(err != nil)
+	if err != nil {
+		cbh.publisher.Group.addDeadLetter(cbh.publisher.Name, event, err.Error())
+	}
+}
+
+func (cbh *CircuitBreakerHandler[T]) invoke(event T) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s: %v", ReasonPanic, r)
+		}
+	}()
+	if fallible, ok := cbh.handler.(FallibleEventHandler[T]); ok {
+		if err = fallible.TryHandler(event); err != nil {
+			err = fmt.Errorf("%s: %w", ReasonHandlerError, err)
+		}
+		return err
+	}
+	cbh.handler.Handler(event)
+	return nil
+}
+
+// State returns the state of the circuit breaker
+func (cbh *CircuitBreakerHandler[T]) State() CircuitState {
+	return cbh.breaker.State()
+}
+
+// Unwrap returns the guarded handler
+func (cbh *CircuitBreakerHandler[T]) Unwrap() EventHandler[T] {
+	return cbh.handler
+}
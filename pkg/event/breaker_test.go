@@ -0,0 +1,91 @@
+package event
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+type flakyHandler struct {
+	fail  bool
+	count int
+}
+
+func (fh *flakyHandler) Handler(event TestEvent) {
+	_ = fh.TryHandler(event)
+}
+
+func (fh *flakyHandler) TryHandler(event TestEvent) error {
+	fh.count++
+	if fh.fail {
+		return errors.New("downstream unavailable")
+	}
+	return nil
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Time{})
+	states := NewGoEventBus[CircuitStateEvent]()
+	states.DefaultConfig = &PublisherConfig{Sync: true}
+	transitions := &stateRecorder{}
+	statePublisher := states.GetPublisher("event.circuit", "default")
+	statePublisher.AddHandler(transitions)
+
+	bus := NewGoEventBus[TestEvent]()
+	publisher := bus.GetPublisherByConfig("event.common", "default", &PublisherConfig{Sync: true}).(*GoPublisher[TestEvent])
+	handler, fallback := &flakyHandler{fail: true}, &countingHandler{}
+	publisher.AddHandler(handler)
+	publisher.EnableCircuitBreaker(&CircuitBreakerConfig{
+		FailureRate: 0.5,
+		MinRequests: 4,
+		CoolDown:    time.Minute,
+		Publisher:   statePublisher,
+		Clock:       fakeClock,
+	}, fallback)
+
+	for i := 0; i < 6; i++ {
+		publisher.Offer(TestEvent{AbstractEvent{Source: i}})
+	}
+	if handler.count != 4 || fallback.count != 2 {
+		t.Errorf("Got %v/%v expected %v/%v", handler.count, fallback.count, 4, 2)
+	}
+	if actualValue, _ := publisher.CircuitState(handler); actualValue != CircuitOpen {
+		t.Errorf("Got %v expected %v", actualValue, CircuitOpen)
+	}
+	if actualValue := bus.Group("event.common").DeadLetters(); actualValue != 4 {
+		t.Errorf("Got %v expected %v", actualValue, 4)
+	}
+
+	// the trial delivery after the cool-down fails, the circuit opens again
+	fakeClock.Advance(time.Minute)
+	publisher.Offer(TestEvent{AbstractEvent{Source: "trial1"}})
+	if actualValue, _ := publisher.CircuitState(handler); actualValue != CircuitOpen {
+		t.Errorf("Got %v expected %v", actualValue, CircuitOpen)
+	}
+
+	handler.fail = false
+	fakeClock.Advance(time.Minute)
+	publisher.Offer(TestEvent{AbstractEvent{Source: "trial2"}})
+	if actualValue, _ := publisher.CircuitState(handler); actualValue != CircuitClosed {
+		t.Errorf("Got %v expected %v", actualValue, CircuitClosed)
+	}
+	expected := []CircuitState{CircuitOpen, CircuitHalfOpen, CircuitOpen, CircuitHalfOpen, CircuitClosed}
+	if len(transitions.states) != len(expected) {
+		t.Fatalf("Got %v expected %v", transitions.states, expected)
+	}
+	for i, state := range expected {
+		if transitions.states[i] != state {
+			t.Errorf("Got %v expected %v", transitions.states, expected)
+		}
+	}
+}
+
+type stateRecorder struct {
+	states []CircuitState
+}
+
+func (sr *stateRecorder) Handler(event CircuitStateEvent) {
+	sr.states = append(sr.states, event.To)
+}
@@ -0,0 +1,246 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/meshware/suit-kit-golang/pkg/encoding/json"
+	"github.com/meshware/suit-kit-golang/pkg/encoding/yaml"
+)
+
+var (
+	ErrUnknownEventType = errors.New("event: unknown event type")
+	ErrEventVersion     = errors.New("event: unsupported event version")
+)
+
+// Codec turns values into bytes and back
+type Codec interface {
+	Name() string
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+var (
+	JSONCodec Codec = jsonCodec{}
+	YAMLCodec Codec = yamlCodec{}
+)
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.MarshalJSON(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.UnmarshalJSONNumber(data, v)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) Name() string {
+	return "yaml"
+}
+
+func (yamlCodec) Marshal(v interface{}) ([]byte, error) {
+	return yaml.MarshalYML(v)
+}
+
+func (yamlCodec) Unmarshal(data []byte, v interface{}) error {
+	return yaml.UnmarshalYML(data, v)
+}
+
+// Upcaster migrates the payload of an event from one schema version to the next one
+type Upcaster func(payload map[string]interface{}) (map[string]interface{}, error)
+
+// Envelope is the serialized form of an event
+type Envelope struct {
+	Type    string      `json:"type" yaml:"type"`
+	Version int         `json:"version" yaml:"version"`
+	Payload interface{} `json:"payload" yaml:"payload"`
+}
+
+type eventType struct {
+	name      string
+	version   int
+	typ       reflect.Type
+	upcasters map[int]Upcaster
+}
+
+// CodecRegistry maps type names to Go event types, and encodes or decodes events with a codec
+type CodecRegistry struct {
+	codec Codec
+	types map[string]*eventType
+	names map[reflect.Type]string
+	mu    sync.RWMutex
+}
+
+func NewCodecRegistry(codec Codec) *CodecRegistry {
+	if codec == nil {
+		codec = JSONCodec
+	}
+	return &CodecRegistry{
+		codec: codec,
+		types: make(map[string]*eventType),
+		names: make(map[reflect.Type]string),
+	}
+}
+
+func (r *CodecRegistry) Codec() Codec {
+	return r.codec
+}
+
+// Register binds the type name to the Go type of the sample event, version is the current schema version
+func (r *CodecRegistry) Register(name string, version int, sample Event) error {
+	if len(name) == 0 || sample == nil {
+		return fmt.Errorf("event: invalid registration of type %q", name)
+	}
+	if version <= 0 {
+		version = 1
+	}
+	typ := reflect.TypeOf(sample)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.types[name]; ok {
+		return fmt.Errorf("event: type %q already registered", name)
+	}
+	if exists, ok := r.names[typ]; ok {
+		return fmt.Errorf("event: %v already registered as %q", typ, exists)
+	}
+	r.types[name] = &eventType{
+		name:      name,
+		version:   version,
+		typ:       typ,
+		upcasters: make(map[int]Upcaster),
+	}
+	r.names[typ] = name
+	return nil
+}
+
+// RegisterUpcaster adds the migration of payloads from fromVersion to fromVersion+1
+func (r *CodecRegistry) RegisterUpcaster(name string, fromVersion int, upcaster Upcaster) error {
+	if upcaster == nil {
+		return fmt.Errorf("event: nil upcaster for type %q", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	et, ok := r.types[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownEventType, name)
+	}
+	if fromVersion <= 0 || fromVersion >= et.version {
+		return fmt.Errorf("%w: %s v%d, current is v%d", ErrEventVersion, name, fromVersion, et.version)
+	}
+	et.upcasters[fromVersion] = upcaster
+	return nil
+}
+
+// TypeName returns the registered type name of the event
+func (r *CodecRegistry) TypeName(event Event) (string, bool) {
+	if event == nil {
+		return "", false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	name, ok := r.names[reflect.TypeOf(event)]
+	return name, ok
+}
+
+// Encode serializes the event into an envelope carrying its type name and version
+func (r *CodecRegistry) Encode(event Event) ([]byte, error) {
+	name, ok := r.TypeName(event)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnknownEventType, event)
+	}
+	r.mu.RLock()
+	version := r.types[name].version
+	r.mu.RUnlock()
+	return r.codec.Marshal(&Envelope{
+		Type:    name,
+		Version: version,
+		Payload: event,
+	})
+}
+
+// Decode deserializes an envelope, upcasting its payload to the current version of the type
+func (r *CodecRegistry) Decode(data []byte) (Event, error) {
+	envelope := &Envelope{}
+	if err := r.codec.Unmarshal(data, envelope); err != nil {
+		return nil, err
+	}
+	r.mu.RLock()
+	et, ok := r.types[envelope.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, envelope.Type)
+	}
+	payload, err := r.upcast(et, envelope)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := r.codec.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	typ := et.typ
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	value := reflect.New(typ)
+	if err = r.codec.Unmarshal(raw, value.Interface()); err != nil {
+		return nil, err
+	}
+	if et.typ.Kind() != reflect.Ptr {
+		value = value.Elem()
+	}
+	return value.Interface().(Event), nil
+}
+
+func (r *CodecRegistry) upcast(et *eventType, envelope *Envelope) (interface{}, error) {
+	version := envelope.Version
+	if version <= 0 {
+		version = 1
+	}
+	if version > et.version {
+		return nil, fmt.Errorf("%w: %s v%d, current is v%d", ErrEventVersion, et.name, version, et.version)
+	}
+	if version == et.version {
+		return envelope.Payload, nil
+	}
+	payload, ok := envelope.Payload.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("event: payload of %s v%d is not an object", et.name, version)
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for ; version < et.version; version++ {
+		upcaster, ok := et.upcasters[version]
+		if !ok {
+			return nil, fmt.Errorf("%w: no upcaster for %s v%d", ErrEventVersion, et.name, version)
+		}
+		var err error
+		if payload, err = upcaster(payload); err != nil {
+			return nil, err
+		}
+	}
+	return payload, nil
+}
+
+// DecodeAs decodes the envelope and asserts the event has type T
+func DecodeAs[T Event](r *CodecRegistry, data []byte) (T, error) {
+	var zero T
+	event, err := r.Decode(data)
+	if err != nil {
+		return zero, err
+	}
+	result, ok := event.(T)
+	if !ok {
+		return zero, fmt.Errorf("event: decoded %T is not %T", event, zero)
+	}
+	return result, nil
+}
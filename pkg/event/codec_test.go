@@ -0,0 +1,62 @@
+package event
+
+import (
+	"errors"
+	"testing"
+)
+
+type OrderCreated struct {
+	AbstractEvent
+	OrderID string `json:"orderId" yaml:"orderId"`
+	Amount  int64  `json:"amount" yaml:"amount"`
+}
+
+func TestCodecRegistryRoundTrip(t *testing.T) {
+	for _, codec := range []Codec{JSONCodec, YAMLCodec} {
+		registry := NewCodecRegistry(codec)
+		if err := registry.Register("order.created", 1, OrderCreated{}); err != nil {
+			t.Fatal(err)
+		}
+		data, err := registry.Encode(OrderCreated{OrderID: "o-1", Amount: 9007199254740993})
+		if err != nil {
+			t.Fatal(err)
+		}
+		event, err := DecodeAs[OrderCreated](registry, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.OrderID != "o-1" || event.Amount != 9007199254740993 {
+			t.Errorf("%s: Got %+v expected %v", codec.Name(), event, "o-1/9007199254740993")
+		}
+	}
+}
+
+func TestCodecRegistryUpcast(t *testing.T) {
+	old := NewCodecRegistry(JSONCodec)
+	_ = old.Register("order.created", 1, &OrderCreated{})
+	data, err := old.Encode(&OrderCreated{OrderID: "o-2", Amount: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewCodecRegistry(JSONCodec)
+	_ = registry.Register("order.created", 2, &OrderCreated{})
+	if _, err = registry.Decode(data); !errors.Is(err, ErrEventVersion) {
+		t.Errorf("Got %v expected %v", err, ErrEventVersion)
+	}
+	_ = registry.RegisterUpcaster("order.created", 1, func(payload map[string]interface{}) (map[string]interface{}, error) {
+		payload["amount"] = 500
+		return payload, nil
+	})
+	event, err := registry.Decode(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actualValue := event.(*OrderCreated).Amount; actualValue != 500 {
+		t.Errorf("Got %v expected %v", actualValue, 500)
+	}
+
+	if _, err = registry.Decode([]byte(`{"type":"order.paid","version":1,"payload":{}}`)); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("Got %v expected %v", err, ErrUnknownEventType)
+	}
+}
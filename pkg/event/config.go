@@ -0,0 +1,113 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/meshware/suit-kit-golang/pkg/encoding/yaml"
+)
+
+// BusConfig declares the publisher groups of an event bus, e.g. in the service yaml:
+//
+//	eventbus:
+//	  strict: false
+//	  groups:
+//	    - name: event.common
+//	      capacity: 1024
+//	      timeout: 5s
+//	      workers: 2
+//	      overflow: drop-oldest
+//	      rateLimit:
+//	        rate: 100
+//	        burst: 20
+//	        mode: delay
+//	        maxDelay: 1s
+type BusConfig struct {
+	Groups []GroupConfig `json:"groups" yaml:"groups"`
+	// Strict refuses the publishers of the groups which are not declared
+	Strict bool `json:"strict" yaml:"strict"`
+}
+
+// GroupConfig declares a publisher group
+type GroupConfig struct {
+	Name            string `json:"name" yaml:"name"`
+	PublisherConfig `yaml:",inline"`
+}
+
+// Validate checks the groups are named once and their settings are in range
+func (bc *BusConfig) Validate() error {
+	var problems []string
+	names := make(map[string]bool, len(bc.Groups))
+	for i, group := range bc.Groups {
+		if len(group.Name) == 0 {
+			problems = append(problems, fmt.Sprintf("groups[%d]: name is empty", i))
+		} else if names[group.Name] {
+			problems = append(problems, fmt.Sprintf("groups[%d]: duplicate group %q", i, group.Name))
+		}
+		names[group.Name] = true
+		if group.Timeout < 0 {
+			problems = append(problems, fmt.Sprintf("groups[%d]: negative timeout %v", i, group.Timeout))
+		}
+		if group.Workers < 0 {
+			problems = append(problems, fmt.Sprintf("groups[%d]: negative workers %d", i, group.Workers))
+		}
+		if !group.Overflow.Valid() {
+			problems = append(problems, fmt.Sprintf("groups[%d]: unknown overflow policy %q", i, group.Overflow))
+		}
+		if limit := group.RateLimit; limit != nil {
+			if limit.Rate <= 0 {
+				problems = append(problems, fmt.Sprintf("groups[%d]: rate limit must be positive", i))
+			}
+			if limit.Burst < 0 || limit.MaxDelay < 0 {
+				problems = append(problems, fmt.Sprintf("groups[%d]: negative rate limit burst or max delay", i))
+			}
+			if !limit.Mode.Valid() {
+				problems = append(problems, fmt.Sprintf("groups[%d]: unknown rate limit mode %q", i, limit.Mode))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("event: invalid bus config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// ParseBusConfig parses the yaml document whose eventbus section declares the groups
+func ParseBusConfig(data []byte) (*BusConfig, error) {
+	root := struct {
+		EventBus *BusConfig `yaml:"eventbus"`
+	}{}
+	if err := yaml.UnmarshalYML(data, &root); err != nil {
+		return nil, err
+	}
+	if root.EventBus == nil {
+		return nil, fmt.Errorf("event: no eventbus section")
+	}
+	return root.EventBus, root.EventBus.Validate()
+}
+
+// LoadBusConfig loads the yml file whose eventbus section declares the groups
+func LoadBusConfig(file string) (*BusConfig, error) {
+	data, err := yaml.LoadYMLConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	return ParseBusConfig(data)
+}
+
+// NewGoEventBusFromConfig creates an event bus with the declared groups
+func NewGoEventBusFromConfig[T Event](config *BusConfig) (*GoEventBus[T], error) {
+	if config == nil {
+		return nil, fmt.Errorf("event: nil bus config")
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+	geb := NewGoEventBus[T]()
+	geb.config = config
+	for _, group := range config.Groups {
+		publisherConfig := group.PublisherConfig
+		geb.Publishers[group.Name] = geb.newGroup(group.Name, &publisherConfig)
+	}
+	return geb, nil
+}
@@ -0,0 +1,70 @@
+package event
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const busYAML = `
+eventbus:
+  strict: true
+  groups:
+    - name: event.common
+      capacity: 2
+      timeout: 3s
+      workers: 2
+      overflow: drop-oldest
+    - name: event.audit
+`
+
+func TestNewGoEventBusFromConfig(t *testing.T) {
+	config, err := ParseBusConfig([]byte(busYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus, err := NewGoEventBusFromConfig[TestEvent](config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actualValue := bus.GroupNames(); len(actualValue) != 2 {
+		t.Errorf("Got %v expected %v", actualValue, "[event.audit event.common]")
+	}
+	dispatcher := bus.Group("event.common").Dispatcher()
+	if dispatcher.Cap() != 2 || dispatcher.Workers() != 2 || dispatcher.timeout != 3*time.Second {
+		t.Errorf("Got %v/%v/%v expected %v/%v/%v", dispatcher.Cap(), dispatcher.Workers(), dispatcher.timeout, 2, 2, 3*time.Second)
+	}
+
+	publisher := bus.GetPublisher("event.common", "default")
+	for _, source := range []string{"hello1", "hello2", "hello3"} {
+		if !publisher.Offer(TestEvent{AbstractEvent{Source: source}}) {
+			t.Errorf("Got %v expected %v", false, true)
+		}
+	}
+	if actualValue := dispatcher.Dropped(); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+
+	if actualValue := bus.GetPublisher("event.unknown", "default"); actualValue != nil {
+		t.Errorf("Got %v expected %v", actualValue, nil)
+	}
+	if actualValue := bus.UnknownGroups(); len(actualValue) != 1 || actualValue[0] != "event.unknown" {
+		t.Errorf("Got %v expected %v", actualValue, "[event.unknown]")
+	}
+}
+
+func TestBusConfigValidate(t *testing.T) {
+	config := &BusConfig{Groups: []GroupConfig{
+		{Name: "event.common"},
+		{Name: "event.common", PublisherConfig: PublisherConfig{Workers: -1, Overflow: "spill"}},
+	}}
+	err := config.Validate()
+	if err == nil {
+		t.Fatalf("Got %v expected an error", err)
+	}
+	for _, problem := range []string{"duplicate group", "negative workers", "unknown overflow policy"} {
+		if !strings.Contains(err.Error(), problem) {
+			t.Errorf("Got %v expected %v", err, problem)
+		}
+	}
+}
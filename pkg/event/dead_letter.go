@@ -0,0 +1,47 @@
+package event
+
+import (
+	"time"
+)
+
+const (
+	// ReasonNoTarget the target of the event is not a handler of the publisher
+	ReasonNoTarget = "no target handler"
+	// ReasonPanic a handler panicked while handling the event
+	ReasonPanic = "handler panic"
+)
+
+// DeadLetter an event which could not be delivered
+type DeadLetter[T Event] struct {
+	Group     string
+	Publisher string
+	Event     T
+	Reason    string
+	Time      time.Time
+}
+
+// deadLetterQueue keeps the latest dead letters up to its capacity
+type deadLetterQueue[T Event] struct {
+	capacity int
+	letters  []DeadLetter[T]
+}
+
+func newDeadLetterQueue[T Event](capacity uint64) *deadLetterQueue[T] {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &deadLetterQueue[T]{capacity: int(capacity)}
+}
+
+func (q *deadLetterQueue[T]) add(letter DeadLetter[T]) {
+	if len(q.letters) >= q.capacity {
+		q.letters = q.letters[1:]
+	}
+	q.letters = append(q.letters, letter)
+}
+
+func (q *deadLetterQueue[T]) drain() []DeadLetter[T] {
+	letters := q.letters
+	q.letters = nil
+	return letters
+}
@@ -3,16 +3,25 @@ package event
 import (
 	"context"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 )
 
 type Dispatcher[T Event] struct {
-	name    string
-	queue   chan *Message[T]
-	stopCh  chan bool
-	started int32
-	timeout time.Duration
+	name     string
+	queue    chan *Message[T]
+	stopCh   chan bool
+	started  int32
+	timeout  time.Duration
+	workers  int
+	overflow OverflowPolicy
+	dropped  uint64
+	// sync publishes the offered messages in the calling goroutine
+	sync bool
+	// resume is not nil while the dispatcher is paused, and is closed on resuming
+	resume chan struct{}
+	mu     sync.Mutex
 }
 
 func NewDispatcher[T Event](name string, config *PublisherConfig) *Dispatcher[T] {
@@ -22,10 +31,16 @@ func NewDispatcher[T Event](name string, config *PublisherConfig) *Dispatcher[T]
 	if config.Timeout <= 0 {
 		config.Timeout = 5 * time.Second
 	}
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
 	return &Dispatcher[T]{
-		name:    name,
-		queue:   make(chan *Message[T], config.Capacity),
-		timeout: config.Timeout,
+		name:     name,
+		queue:    make(chan *Message[T], config.Capacity),
+		timeout:  config.Timeout,
+		workers:  config.Workers,
+		overflow: config.Overflow,
+		sync:     config.Sync,
 	}
 }
 
@@ -33,9 +48,31 @@ func (d *Dispatcher[T]) Offer(message *Message[T]) bool {
 	if message == nil {
 		return false
 	}
+	if d.sync && !d.IsPaused() {
+		message.Publish()
+		return true
+	}
 	select {
 	case d.queue <- message:
 		return true
+	default:
+	}
+	switch d.overflow {
+	case OverflowBlock:
+		return d.OfferWithTimeout(message, d.timeout)
+	case OverflowDropOldest:
+		for {
+			select {
+			case d.queue <- message:
+				return true
+			default:
+			}
+			select {
+			case <-d.queue:
+				atomic.AddUint64(&d.dropped, 1)
+			default:
+			}
+		}
 	default:
 		return false
 	}
@@ -45,6 +82,10 @@ func (d *Dispatcher[T]) OfferWithTimeout(message *Message[T], timeout time.Durat
 	if message == nil {
 		return false
 	}
+	if d.sync && !d.IsPaused() {
+		message.Publish()
+		return true
+	}
 	select {
 	case d.queue <- message:
 		return true
@@ -54,9 +95,11 @@ func (d *Dispatcher[T]) OfferWithTimeout(message *Message[T], timeout time.Durat
 }
 
 func (d *Dispatcher[T]) Start(ctx context.Context) error {
-	if atomic.CompareAndSwapInt32(&d.started, 0, 1) {
+	if atomic.CompareAndSwapInt32(&d.started, 0, 1) && !d.sync {
 		d.stopCh = make(chan bool)
-		go d.worker(d.stopCh)
+		for i := 0; i < d.workers; i++ {
+			go d.worker(d.stopCh)
+		}
 	}
 	return nil
 }
@@ -65,6 +108,14 @@ func (d *Dispatcher[T]) worker(stopCh <-chan bool) {
 	for {
 		select {
 		default:
+			if resume := d.paused(); resume != nil {
+				select {
+				case <-resume:
+				case <-stopCh:
+					return
+				}
+				continue
+			}
 			d.Publish()
 		case <-stopCh:
 			fmt.Println("Dispatcher stopped")
@@ -85,9 +136,76 @@ func (d *Dispatcher[T]) Publish() {
 func (d *Dispatcher[T]) Stop(ctx context.Context) error {
 	if atomic.CompareAndSwapInt32(&d.started, 1, 0) {
 		if d.stopCh != nil {
-			d.stopCh <- true
+			close(d.stopCh)
 			d.stopCh = nil
 		}
 	}
 	return nil
 }
+
+// Pause stops taking messages from the queue, offers are still accepted until the queue is full
+func (d *Dispatcher[T]) Pause() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.resume == nil {
+		d.resume = make(chan struct{})
+	}
+}
+
+// Resume continues publishing the queued messages, a synchronous dispatcher publishes them before returning
+func (d *Dispatcher[T]) Resume() {
+	d.mu.Lock()
+	if d.resume != nil {
+		close(d.resume)
+		d.resume = nil
+	}
+	d.mu.Unlock()
+	if d.sync {
+		for {
+			select {
+			case message := <-d.queue:
+				message.Publish()
+			default:
+				return
+			}
+		}
+	}
+}
+
+func (d *Dispatcher[T]) paused() chan struct{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.resume
+}
+
+func (d *Dispatcher[T]) IsPaused() bool {
+	return d.paused() != nil
+}
+
+func (d *Dispatcher[T]) IsStarted() bool {
+	return atomic.LoadInt32(&d.started) == 1
+}
+
+func (d *Dispatcher[T]) Name() string {
+	return d.name
+}
+
+// Len returns the number of queued messages
+func (d *Dispatcher[T]) Len() int {
+	return len(d.queue)
+}
+
+// Cap returns the capacity of the queue
+func (d *Dispatcher[T]) Cap() int {
+	return cap(d.queue)
+}
+
+// Workers returns the number of goroutines publishing the queued messages
+func (d *Dispatcher[T]) Workers() int {
+	return d.workers
+}
+
+// Dropped returns the number of queued messages evicted by the drop-oldest overflow policy
+func (d *Dispatcher[T]) Dropped() uint64 {
+	return atomic.LoadUint64(&d.dropped)
+}
@@ -1,5 +1,12 @@
 package event
 
+import (
+	"sort"
+	"sync"
+
+	"github.com/meshware/suit-kit-golang/pkg/collection"
+)
+
 type EventBus[T Event] interface {
 	GetPublisherByConfig(group, name string, config *PublisherConfig) Publisher[T]
 	GetPublisher(group, name string) Publisher[T]
@@ -8,11 +15,20 @@ type EventBus[T Event] interface {
 type GoEventBus[T Event] struct {
 	EventBus[T]
 	Publishers map[string]*PublisherGroup[T]
+	// DefaultConfig is used by the groups created without config
+	DefaultConfig *PublisherConfig
+	// config declares the groups of a bus created by NewGoEventBusFromConfig
+	config        *BusConfig
+	unknownGroups *collection.HashSet[string]
+	topics        *TopicTrie[T]
+	mu            sync.RWMutex
 }
 
 func NewGoEventBus[T Event]() *GoEventBus[T] {
 	return &GoEventBus[T]{
-		Publishers: make(map[string]*PublisherGroup[T]),
+		Publishers:    make(map[string]*PublisherGroup[T]),
+		unknownGroups: collection.NewSet[string](),
+		topics:        NewTopicTrie[T](),
 	}
 }
 
@@ -24,8 +40,66 @@ func (geb *GoEventBus[T]) GetPublisherByConfig(group, name string, config *Publi
 	if len(group) == 0 || len(name) == 0 {
 		return nil
 	}
+	geb.mu.Lock()
+	defer geb.mu.Unlock()
+	if config == nil && geb.DefaultConfig != nil {
+		defaults := *geb.DefaultConfig
+		config = &defaults
+	}
 	if _, ok := geb.Publishers[group]; !ok {
-		geb.Publishers[group] = NewPublisherGroup[T](name, config)
+		if geb.config != nil {
+			geb.unknownGroups.Add(group)
+			if geb.config.Strict {
+				return nil
+			}
+		}
+		geb.Publishers[group] = geb.newGroup(group, config)
 	}
 	return geb.Publishers[group].GetPublisher(name)
 }
+
+func (geb *GoEventBus[T]) newGroup(name string, config *PublisherConfig) *PublisherGroup[T] {
+	group := NewPublisherGroup[T](name, config)
+	group.topics = geb.topics
+	return group
+}
+
+// Subscribe delivers the events of all the publishers whose topic matches the pattern to the handler.
+// The topic of a publisher is its group and name joined by TopicSeparator, "*" matches one level and
+// "#" matches any number of levels, e.g. "order.*" or "order.#".
+func (geb *GoEventBus[T]) Subscribe(pattern string, handler EventHandler[T]) error {
+	return geb.topics.Subscribe(pattern, handler)
+}
+
+// Unsubscribe removes the handler subscribed to the pattern
+func (geb *GoEventBus[T]) Unsubscribe(pattern string, handler EventHandler[T]) bool {
+	return geb.topics.Unsubscribe(pattern, handler)
+}
+
+// Group returns the publisher group by name, nil if absent
+func (geb *GoEventBus[T]) Group(name string) *PublisherGroup[T] {
+	geb.mu.RLock()
+	defer geb.mu.RUnlock()
+	return geb.Publishers[name]
+}
+
+// GroupNames returns the sorted names of the publisher groups
+func (geb *GoEventBus[T]) GroupNames() []string {
+	geb.mu.RLock()
+	defer geb.mu.RUnlock()
+	names := make([]string, 0, len(geb.Publishers))
+	for name := range geb.Publishers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// UnknownGroups returns the sorted groups requested at runtime but not declared in the bus config
+func (geb *GoEventBus[T]) UnknownGroups() []string {
+	geb.mu.RLock()
+	defer geb.mu.RUnlock()
+	groups := geb.unknownGroups.Values()
+	sort.Strings(groups)
+	return groups
+}
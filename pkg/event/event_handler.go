@@ -23,3 +23,9 @@ func NewPredicateEventHandler[T Event](predicateFunc Predicate[T], handler Event
 		PredicateFunc: predicateFunc,
 	}
 }
+
+// FallibleEventHandler is an EventHandler able to report that handling an event failed
+type FallibleEventHandler[T Event] interface {
+	EventHandler[T]
+	TryHandler(event T) error
+}
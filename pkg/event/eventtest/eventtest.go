@@ -0,0 +1,182 @@
+// Package eventtest provides helpers to test code built on the event bus
+// deterministically, without sleeping for the dispatchers.
+package eventtest
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/event"
+)
+
+// SyncConfig returns a publisher config which delivers the offered events in-line
+func SyncConfig() *event.PublisherConfig {
+	return &event.PublisherConfig{Sync: true}
+}
+
+// NewSyncBus creates an event bus whose groups deliver the offered events before Offer returns
+func NewSyncBus[T event.Event]() *event.GoEventBus[T] {
+	bus := event.NewGoEventBus[T]()
+	bus.DefaultConfig = SyncConfig()
+	return bus
+}
+
+// Matcher matches the recorded events
+type Matcher[T event.Event] func(event T) bool
+
+// MatchAll matches every event
+func MatchAll[T event.Event]() Matcher[T] {
+	return func(event T) bool {
+		return true
+	}
+}
+
+// MatchSource matches the events whose source deeply equals the source
+func MatchSource[T event.Event](source interface{}) Matcher[T] {
+	return func(event T) bool {
+		return reflect.DeepEqual(event.GetSource(), source)
+	}
+}
+
+// MatchTarget matches the events whose target deeply equals the target
+func MatchTarget[T event.Event](target interface{}) Matcher[T] {
+	return func(event T) bool {
+		return reflect.DeepEqual(event.GetTarget(), target)
+	}
+}
+
+// And matches the events matched by all the matchers
+func And[T event.Event](matchers ...Matcher[T]) Matcher[T] {
+	return func(event T) bool {
+		for _, matcher := range matchers {
+			if !matcher(event) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or matches the events matched by any of the matchers
+func Or[T event.Event](matchers ...Matcher[T]) Matcher[T] {
+	return func(event T) bool {
+		for _, matcher := range matchers {
+			if matcher(event) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not matches the events not matched by the matcher
+func Not[T event.Event](matcher Matcher[T]) Matcher[T] {
+	return func(event T) bool {
+		return !matcher(event)
+	}
+}
+
+// RecordingHandler an event handler capturing the handled events
+type RecordingHandler[T event.Event] struct {
+	events []T
+	// changed is closed and replaced each time an event is recorded
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+var _ event.EventHandler[event.AbstractEvent] = &RecordingHandler[event.AbstractEvent]{}
+
+func NewRecordingHandler[T event.Event]() *RecordingHandler[T] {
+	return &RecordingHandler[T]{changed: make(chan struct{})}
+}
+
+func (rh *RecordingHandler[T]) Handler(event T) {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.events = append(rh.events, event)
+	close(rh.changed)
+	rh.changed = make(chan struct{})
+}
+
+// Events returns a copy of the recorded events in handling order
+func (rh *RecordingHandler[T]) Events() []T {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	return append([]T(nil), rh.events...)
+}
+
+func (rh *RecordingHandler[T]) Len() int {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	return len(rh.events)
+}
+
+// Reset forgets the recorded events
+func (rh *RecordingHandler[T]) Reset() {
+	rh.mu.Lock()
+	defer rh.mu.Unlock()
+	rh.events = nil
+}
+
+// Matching returns the recorded events matched by the matcher
+func (rh *RecordingHandler[T]) Matching(matcher Matcher[T]) []T {
+	var result []T
+	for _, e := range rh.Events() {
+		if matcher(e) {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// Count returns the number of recorded events matched by the matcher
+func (rh *RecordingHandler[T]) Count(matcher Matcher[T]) int {
+	return len(rh.Matching(matcher))
+}
+
+// AwaitEvents waits until at least n events are recorded and returns them, the test fails on timeout
+func (rh *RecordingHandler[T]) AwaitEvents(t testing.TB, n int, timeout time.Duration) []T {
+	t.Helper()
+	return rh.AwaitMatching(t, MatchAll[T](), n, timeout)
+}
+
+// AwaitMatching waits until at least n recorded events are matched by the matcher and returns them,
+// the test fails on timeout
+func (rh *RecordingHandler[T]) AwaitMatching(t testing.TB, matcher Matcher[T], n int, timeout time.Duration) []T {
+	t.Helper()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		rh.mu.Lock()
+		changed := rh.changed
+		rh.mu.Unlock()
+		if matched := rh.Matching(matcher); len(matched) >= n {
+			return matched
+		}
+		select {
+		case <-changed:
+		case <-timer.C:
+			matched := rh.Matching(matcher)
+			t.Fatalf("timed out after %v waiting for %d events, got %d", timeout, n, len(matched))
+			return matched
+		}
+	}
+}
+
+// AssertReceived fails the test unless an event matched by the matcher is recorded
+func (rh *RecordingHandler[T]) AssertReceived(t testing.TB, matcher Matcher[T]) {
+	t.Helper()
+	if rh.Count(matcher) == 0 {
+		t.Errorf("no matching event in %d recorded events", rh.Len())
+	}
+}
+
+// AssertNotReceived fails the test if an event matched by the matcher is recorded
+func (rh *RecordingHandler[T]) AssertNotReceived(t testing.TB, matcher Matcher[T]) {
+	t.Helper()
+	if count := rh.Count(matcher); count > 0 {
+		t.Errorf("Got %v matching events expected %v", count, 0)
+	}
+}
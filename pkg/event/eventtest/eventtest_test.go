@@ -0,0 +1,49 @@
+package eventtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/event"
+)
+
+type TestEvent struct {
+	event.AbstractEvent
+}
+
+func TestSyncBus(t *testing.T) {
+	publisher := NewSyncBus[TestEvent]().GetPublisher("event.common", "default")
+	recorder := NewRecordingHandler[TestEvent]()
+	publisher.AddHandler(recorder)
+	if err := publisher.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	publisher.Offer(TestEvent{event.AbstractEvent{Source: "hello1"}})
+	publisher.Offer(TestEvent{event.AbstractEvent{Source: "hello2", Target: recorder}})
+	if actualValue := recorder.Len(); actualValue != 2 {
+		t.Errorf("Got %v expected %v", actualValue, 2)
+	}
+	recorder.AssertReceived(t, MatchSource[TestEvent]("hello1"))
+	recorder.AssertNotReceived(t, MatchSource[TestEvent]("hello3"))
+	if actualValue := recorder.Count(And(MatchSource[TestEvent]("hello2"), MatchTarget[TestEvent](recorder))); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
+
+func TestAwaitEvents(t *testing.T) {
+	publisher := event.NewGoEventBus[TestEvent]().GetPublisher("event.common", "default")
+	recorder := NewRecordingHandler[TestEvent]()
+	publisher.AddHandler(recorder)
+	if err := publisher.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	publisher.Offer(TestEvent{event.AbstractEvent{Source: "hello1"}})
+	publisher.Offer(TestEvent{event.AbstractEvent{Source: "hello2"}})
+	events := recorder.AwaitEvents(t, 2, time.Second)
+	if events[0].GetSource() != "hello1" || events[1].GetSource() != "hello2" {
+		t.Errorf("Got %v expected %v", events, "hello1, hello2")
+	}
+}
@@ -2,6 +2,7 @@ package event
 
 import (
 	"context"
+	"fmt"
 	"github.com/meshware/suit-kit-golang/pkg/lifecycle"
 	"sync"
 	"time"
@@ -17,10 +18,38 @@ type Publisher[T Event] interface {
 	OfferWithTimeout(event T, duration time.Duration) bool
 }
 
+// OverflowPolicy decides what Offer does when the queue of the group is full
+type OverflowPolicy string
+
+const (
+	// OverflowReject rejects the offered event, this is the default policy
+	OverflowReject OverflowPolicy = "reject"
+	// OverflowBlock waits for room in the queue until the timeout of the group
+	OverflowBlock OverflowPolicy = "block"
+	// OverflowDropOldest evicts the oldest queued event to make room for the offered one
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+)
+
+// Valid reports whether the policy is known, the empty policy means OverflowReject
+func (op OverflowPolicy) Valid() bool {
+	switch op {
+	case "", OverflowReject, OverflowBlock, OverflowDropOldest:
+		return true
+	}
+	return false
+}
+
 // PublisherConfig Configure publisher-related queue length and timeout
 type PublisherConfig struct {
-	Capacity uint64        `json:"capacity"`
-	Timeout  time.Duration `json:"timeout"`
+	Capacity           uint64         `json:"capacity" yaml:"capacity"`
+	Timeout            time.Duration  `json:"timeout" yaml:"timeout"`
+	Workers            int            `json:"workers" yaml:"workers"`
+	Overflow           OverflowPolicy `json:"overflow" yaml:"overflow"`
+	DeadLetterCapacity uint64         `json:"deadLetterCapacity" yaml:"deadLetterCapacity"`
+	// RateLimit limits the offers of all the publishers of the group if set
+	RateLimit *RateLimitConfig `json:"rateLimit" yaml:"rateLimit"`
+	// Sync publishes the events in the goroutine calling Offer instead of queueing them, mainly for tests
+	Sync bool `json:"sync" yaml:"sync"`
 }
 
 type GoPublisher[T Event] struct {
@@ -30,6 +59,11 @@ type GoPublisher[T Event] struct {
 	Polling  *Dispatcher[T]
 	Handlers map[interface{}]EventHandler[T]
 	Consumer func(event T)
+	// breaker guards the handlers with circuit breakers once enabled
+	breaker  *CircuitBreakerConfig
+	fallback EventHandler[T]
+	limiter  *RateLimiter
+	mu       sync.RWMutex
 }
 
 func NewGoPublisher[T Event](name string, group *PublisherGroup[T]) *GoPublisher[T] {
@@ -43,7 +77,9 @@ func NewGoPublisher[T Event](name string, group *PublisherGroup[T]) *GoPublisher
 
 func (gp *GoPublisher[T]) AddHandler(handler EventHandler[T]) bool {
 	if handler != nil {
-		gp.Handlers[handler] = handler
+		gp.mu.Lock()
+		defer gp.mu.Unlock()
+		gp.Handlers[handler] = gp.guard(handler)
 		return true
 	} else {
 		return false
@@ -52,6 +88,8 @@ func (gp *GoPublisher[T]) AddHandler(handler EventHandler[T]) bool {
 
 func (gp *GoPublisher[T]) RemoveHandler(handler EventHandler[T]) bool {
 	if handler != nil {
+		gp.mu.Lock()
+		defer gp.mu.Unlock()
 		delete(gp.Handlers, handler)
 		return true
 	} else {
@@ -60,31 +98,175 @@ func (gp *GoPublisher[T]) RemoveHandler(handler EventHandler[T]) bool {
 }
 
 func (gp *GoPublisher[T]) Size() int {
+	gp.mu.RLock()
+	defer gp.mu.RUnlock()
 	return len(gp.Handlers)
 }
 
+// HandlerTypes returns the number of handlers by their type name
+func (gp *GoPublisher[T]) HandlerTypes() map[string]int {
+	gp.mu.RLock()
+	defer gp.mu.RUnlock()
+	types := make(map[string]int, len(gp.Handlers))
+	for _, handler := range gp.Handlers {
+		if wrapper, ok := handler.(interface{ Unwrap() EventHandler[T] }); ok {
+			handler = wrapper.Unwrap()
+		}
+		types[fmt.Sprintf("%T", handler)]++
+	}
+	return types
+}
+
+// EnableCircuitBreaker guards each handler, added before or after, with its own circuit breaker.
+// The events skipped while a circuit is open go to the fallback handler, or to the dead letters if it is nil.
+func (gp *GoPublisher[T]) EnableCircuitBreaker(config *CircuitBreakerConfig, fallback EventHandler[T]) {
+	if config == nil {
+		config = &CircuitBreakerConfig{}
+	}
+	resolved := config.withDefaults()
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+	gp.breaker = &resolved
+	gp.fallback = fallback
+	for key, handler := range gp.Handlers {
+		gp.Handlers[key] = gp.guard(handler)
+	}
+}
+
+// CircuitState returns the state of the circuit breaker guarding the handler
+func (gp *GoPublisher[T]) CircuitState(handler EventHandler[T]) (CircuitState, bool) {
+	gp.mu.RLock()
+	defer gp.mu.RUnlock()
+	if guarded, ok := gp.Handlers[handler].(*CircuitBreakerHandler[T]); ok {
+		return guarded.State(), true
+	}
+	return CircuitClosed, false
+}
+
+func (gp *GoPublisher[T]) guard(handler EventHandler[T]) EventHandler[T] {
+	if gp.breaker == nil {
+		return handler
+	}
+	if guarded, ok := handler.(*CircuitBreakerHandler[T]); ok {
+		handler = guarded.Unwrap()
+	}
+	return newCircuitBreakerHandler[T](gp, handler, *gp.breaker, gp.fallback)
+}
+
 func (gp *GoPublisher[T]) Offer(event T) bool {
-	return gp.Polling != nil && gp.Polling.Offer(NewMessage(event, gp.publish))
+	if gp.Polling == nil {
+		return false
+	}
+	limiters, ok := gp.acquire(-1)
+	if !ok {
+		return false
+	}
+	if !gp.Polling.Offer(NewMessage(event, gp.publish)) {
+		release(limiters...)
+		return false
+	}
+	return true
 }
 
 func (gp *GoPublisher[T]) OfferWithTimeout(event T, duration time.Duration) bool {
-	return gp.Polling != nil && gp.Polling.OfferWithTimeout(NewMessage(event, gp.publish), duration)
+	if gp.Polling == nil {
+		return false
+	}
+	limiters, ok := gp.acquire(duration)
+	if !ok {
+		return false
+	}
+	if !gp.Polling.OfferWithTimeout(NewMessage(event, gp.publish), duration) {
+		release(limiters...)
+		return false
+	}
+	return true
+}
+
+// SetRateLimit limits the offers of the publisher in addition to the limit of its group, nil removes the limit
+func (gp *GoPublisher[T]) SetRateLimit(config *RateLimitConfig) {
+	limiter := NewRateLimiter(config)
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+	gp.limiter = limiter
+}
+
+// acquire takes a token from the limiters of the publisher and its group, it returns the limiters to
+// release if the offer is rejected
+func (gp *GoPublisher[T]) acquire(timeout time.Duration) ([]*RateLimiter, bool) {
+	gp.mu.RLock()
+	limiter := gp.limiter
+	gp.mu.RUnlock()
+	if limiter == nil && gp.Group.limiter == nil {
+		return nil, true
+	}
+	limiters := []*RateLimiter{limiter, gp.Group.limiter}
+	return limiters, acquire(timeout, limiters...)
+}
+
+// Topic returns the group and name of the publisher joined by TopicSeparator
+func (gp *GoPublisher[T]) Topic() string {
+	return gp.Group.name + TopicSeparator + gp.Name
 }
 
 func (gp *GoPublisher[T]) publish(event T) {
 	if event.GetTarget() != nil {
+		gp.mu.RLock()
 		handler := gp.Handlers[event.GetTarget()]
+		gp.mu.RUnlock()
+		if handler == nil {
+			for _, subscriber := range gp.subscribers() {
+				if subscriber == event.GetTarget() {
+					handler = subscriber
+					break
+				}
+			}
+		}
 		if handler != nil {
-			handler.Handler(event)
+			gp.handle(handler, event)
 			return
 		}
+		gp.Group.addDeadLetter(gp.Name, event, ReasonNoTarget)
 	} else {
-		for _, handler := range gp.Handlers {
-			handler.Handler(event)
+		for _, handler := range gp.handlers() {
+			gp.handle(handler, event)
 		}
 	}
 }
 
+// handlers returns the handlers of the publisher followed by the subscribers of its topic
+func (gp *GoPublisher[T]) handlers() []EventHandler[T] {
+	subscribers := gp.subscribers()
+	gp.mu.RLock()
+	defer gp.mu.RUnlock()
+	result := &handlerSet[T]{
+		handlers: make([]EventHandler[T], 0, len(gp.Handlers)+len(subscribers)),
+		seen:     make(map[interface{}]bool, len(gp.Handlers)),
+	}
+	for _, handler := range gp.Handlers {
+		result.add(handler)
+	}
+	result.add(subscribers...)
+	return result.handlers
+}
+
+func (gp *GoPublisher[T]) subscribers() []EventHandler[T] {
+	if gp.Group.topics == nil {
+		return nil
+	}
+	return gp.Group.topics.Match(gp.Topic())
+}
+
+// handle keeps the dispatcher alive when a handler panics, the event becomes a dead letter
+func (gp *GoPublisher[T]) handle(handler EventHandler[T], event T) {
+	defer func() {
+		if r := recover(); r != nil {
+			gp.Group.addDeadLetter(gp.Name, event, fmt.Sprintf("%s: %v", ReasonPanic, r))
+		}
+	}()
+	handler.Handler(event)
+}
+
 func (gp *GoPublisher[T]) Start(ctx context.Context) error {
 	if gp.Polling == nil {
 		if !gp.Group.Contains(gp.Name) {
@@ -102,11 +284,15 @@ func (gp *GoPublisher[T]) Stop(ctx context.Context) error {
 }
 
 type PublisherGroup[T Event] struct {
-	name       string
-	config     *PublisherConfig
-	dispatcher *Dispatcher[T]
-	publishers map[string]*GoPublisher[T]
-	mu         sync.Mutex
+	name        string
+	config      *PublisherConfig
+	dispatcher  *Dispatcher[T]
+	publishers  map[string]*GoPublisher[T]
+	deadLetters *deadLetterQueue[T]
+	// topics holds the subscriptions of the bus owning the group
+	topics  *TopicTrie[T]
+	limiter *RateLimiter
+	mu      sync.Mutex
 }
 
 func NewPublisherGroup[T Event](name string, config *PublisherConfig) *PublisherGroup[T] {
@@ -119,13 +305,60 @@ func NewPublisherGroup[T Event](name string, config *PublisherConfig) *Publisher
 	}
 	dispatcher := NewDispatcher[T]("GoEventBus-"+name, config)
 	return &PublisherGroup[T]{
-		name:       name,
-		config:     config,
-		dispatcher: dispatcher,
-		publishers: make(map[string]*GoPublisher[T]),
+		name:        name,
+		config:      config,
+		dispatcher:  dispatcher,
+		publishers:  make(map[string]*GoPublisher[T]),
+		deadLetters: newDeadLetterQueue[T](config.DeadLetterCapacity),
+		limiter:     NewRateLimiter(config.RateLimit),
 	}
 }
 
+func (pg *PublisherGroup[T]) Name() string {
+	return pg.name
+}
+
+func (pg *PublisherGroup[T]) Dispatcher() *Dispatcher[T] {
+	return pg.dispatcher
+}
+
+// Publishers returns a snapshot of the publishers of the group
+func (pg *PublisherGroup[T]) Publishers() []*GoPublisher[T] {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+	publishers := make([]*GoPublisher[T], 0, len(pg.publishers))
+	for _, publisher := range pg.publishers {
+		publishers = append(publishers, publisher)
+	}
+	return publishers
+}
+
+func (pg *PublisherGroup[T]) addDeadLetter(publisher string, event T, reason string) {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+	pg.deadLetters.add(DeadLetter[T]{
+		Group:     pg.name,
+		Publisher: publisher,
+		Event:     event,
+		Reason:    reason,
+		Time:      time.Now(),
+	})
+}
+
+// DeadLetters returns the number of dead letters kept by the group
+func (pg *PublisherGroup[T]) DeadLetters() int {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+	return len(pg.deadLetters.letters)
+}
+
+// DrainDeadLetters removes and returns the dead letters kept by the group
+func (pg *PublisherGroup[T]) DrainDeadLetters() []DeadLetter[T] {
+	pg.mu.Lock()
+	defer pg.mu.Unlock()
+	return pg.deadLetters.drain()
+}
+
 func (pg *PublisherGroup[T]) Contains(name string) bool {
 	pg.mu.Lock()
 	defer pg.mu.Unlock()
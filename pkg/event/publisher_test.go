@@ -0,0 +1,57 @@
+package event_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/meshware/suit-kit-golang/pkg/event"
+	"github.com/meshware/suit-kit-golang/pkg/event/eventtest"
+)
+
+type testEvent struct {
+	event.AbstractEvent
+}
+
+func sources(events []testEvent) []interface{} {
+	result := make([]interface{}, 0, len(events))
+	for _, e := range events {
+		result = append(result, e.GetSource())
+	}
+	return result
+}
+
+func TestPublisher(t *testing.T) {
+	bus := event.NewGoEventBus[testEvent]()
+	publisher := bus.GetPublisherByConfig("event.common", "default", &event.PublisherConfig{Sync: true})
+	if err := publisher.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	recorder := eventtest.NewRecordingHandler[testEvent]()
+	predicated := eventtest.NewRecordingHandler[testEvent]()
+	predicateEventHandler := event.NewPredicateEventHandler[testEvent](
+		func(event testEvent) bool {
+			return true
+		},
+		predicated,
+	)
+	publisher.AddHandler(recorder)
+	publisher.AddHandler(predicateEventHandler)
+
+	publisher.Offer(testEvent{event.AbstractEvent{Source: "hello1"}})
+	// the target is not a handler of the publisher, the event is a dead letter
+	publisher.Offer(testEvent{event.AbstractEvent{Source: "hello2", Target: eventtest.NewRecordingHandler[testEvent]()}})
+	publisher.Offer(testEvent{event.AbstractEvent{Source: "hello3"}})
+	publisher.Offer(testEvent{event.AbstractEvent{Source: "hello4"}})
+	publisher.Offer(testEvent{event.AbstractEvent{Source: "hello5", Target: predicateEventHandler}})
+
+	if actualValue, expected := sources(recorder.Events()), []interface{}{"hello1", "hello3", "hello4"}; !reflect.DeepEqual(actualValue, expected) {
+		t.Errorf("Got %v expected %v", actualValue, expected)
+	}
+	if actualValue, expected := sources(predicated.Events()), []interface{}{"hello1", "hello3", "hello4", "hello5"}; !reflect.DeepEqual(actualValue, expected) {
+		t.Errorf("Got %v expected %v", actualValue, expected)
+	}
+	if actualValue := bus.Group("event.common").DeadLetters(); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
@@ -0,0 +1,168 @@
+package event
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+// RateLimitMode decides what an offer over the limit does
+type RateLimitMode string
+
+const (
+	// RateLimitReject rejects the offers over the limit, this is the default mode
+	RateLimitReject RateLimitMode = "reject"
+	// RateLimitDelay delays the offers over the limit until a token is available, at most MaxDelay
+	RateLimitDelay RateLimitMode = "delay"
+)
+
+// Valid reports whether the mode is known, the empty mode means RateLimitReject
+func (rm RateLimitMode) Valid() bool {
+	return rm == "" || rm == RateLimitReject || rm == RateLimitDelay
+}
+
+// RateLimitConfig Configure the token bucket limiting the offers of a publisher or a group
+type RateLimitConfig struct {
+	// Rate is the number of offers allowed per second
+	Rate float64 `json:"rate" yaml:"rate"`
+	// Burst is the number of offers allowed at once, 1 by default
+	Burst int           `json:"burst" yaml:"burst"`
+	Mode  RateLimitMode `json:"mode" yaml:"mode"`
+	// MaxDelay bounds the wait of an offer in delay mode, OfferWithTimeout waits at most its timeout
+	MaxDelay time.Duration `json:"maxDelay" yaml:"maxDelay"`
+	Clock    clock.Clock   `json:"-" yaml:"-"`
+}
+
+// TokenBucket a token bucket refilled at a constant rate
+type TokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  clock.Clock
+	mu     sync.Mutex
+}
+
+func NewTokenBucket(rate float64, burst int, c clock.Clock) *TokenBucket {
+	if burst <= 0 {
+		burst = 1
+	}
+	c = clock.OrReal(c)
+	return &TokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   c.Now(),
+		clock:  c,
+	}
+}
+
+// Allow takes a token if one is available
+func (tb *TokenBucket) Allow() bool {
+	_, ok := tb.Reserve(0)
+	return ok
+}
+
+// Reserve takes a token and returns how long to wait before using it, no token is taken if the wait
+// would exceed maxWait
+func (tb *TokenBucket) Reserve(maxWait time.Duration) (time.Duration, bool) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	now := tb.clock.Now()
+	if elapsed := now.Sub(tb.last); elapsed > 0 {
+		tb.tokens = math.Min(tb.burst, tb.tokens+elapsed.Seconds()*tb.rate)
+		tb.last = now
+	}
+	if tb.tokens >= 1 {
+		tb.tokens--
+		return 0, true
+	}
+	if tb.rate <= 0 {
+		return 0, false
+	}
+	wait := time.Duration((1 - tb.tokens) / tb.rate * float64(time.Second))
+	if wait > maxWait {
+		return 0, false
+	}
+	tb.tokens--
+	return wait, true
+}
+
+// Cancel gives back a reserved token
+func (tb *TokenBucket) Cancel() {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.tokens = math.Min(tb.burst, tb.tokens+1)
+}
+
+// RateLimiter limits the offers of a publisher or a group
+type RateLimiter struct {
+	bucket   *TokenBucket
+	mode     RateLimitMode
+	maxDelay time.Duration
+	clock    clock.Clock
+}
+
+func NewRateLimiter(config *RateLimitConfig) *RateLimiter {
+	if config == nil {
+		return nil
+	}
+	c := clock.OrReal(config.Clock)
+	return &RateLimiter{
+		bucket:   NewTokenBucket(config.Rate, config.Burst, c),
+		mode:     config.Mode,
+		maxDelay: config.MaxDelay,
+		clock:    c,
+	}
+}
+
+// reserve takes a token, waiting at most timeout in delay mode, a negative timeout means MaxDelay
+func (rl *RateLimiter) reserve(timeout time.Duration) (time.Duration, bool) {
+	if rl == nil {
+		return 0, true
+	}
+	maxWait := time.Duration(0)
+	if rl.mode == RateLimitDelay {
+		maxWait = rl.maxDelay
+		if timeout >= 0 && (timeout < maxWait || maxWait <= 0) {
+			maxWait = timeout
+		}
+	}
+	return rl.bucket.Reserve(maxWait)
+}
+
+func (rl *RateLimiter) cancel() {
+	if rl != nil {
+		rl.bucket.Cancel()
+	}
+}
+
+// acquire takes a token from every limiter and waits for the longest reservation, the tokens are given
+// back if any limiter rejects
+func acquire(timeout time.Duration, limiters ...*RateLimiter) bool {
+	var wait time.Duration
+	var c clock.Clock
+	for i, limiter := range limiters {
+		delay, ok := limiter.reserve(timeout)
+		if !ok {
+			release(limiters[:i]...)
+			return false
+		}
+		if delay > wait {
+			wait, c = delay, limiter.clock
+		}
+	}
+	if wait > 0 {
+		c.Sleep(wait)
+	}
+	return true
+}
+
+// release gives back the tokens taken by acquire
+func release(limiters ...*RateLimiter) {
+	for _, limiter := range limiters {
+		limiter.cancel()
+	}
+}
@@ -0,0 +1,105 @@
+package event
+
+import (
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+func TestTokenBucket(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Time{})
+	bucket := NewTokenBucket(2, 3, fakeClock)
+	for i := 0; i < 3; i++ {
+		if actualValue := bucket.Allow(); actualValue != true {
+			t.Errorf("Got %v expected %v", actualValue, true)
+		}
+	}
+	if actualValue := bucket.Allow(); actualValue != false {
+		t.Errorf("Got %v expected %v", actualValue, false)
+	}
+	if wait, ok := bucket.Reserve(time.Second); !ok || wait != 500*time.Millisecond {
+		t.Errorf("Got %v %v expected %v %v", wait, ok, 500*time.Millisecond, true)
+	}
+	fakeClock.Advance(500 * time.Millisecond)
+	if actualValue := bucket.Allow(); actualValue != false {
+		t.Errorf("Got %v expected %v", actualValue, false)
+	}
+	fakeClock.Advance(10 * time.Second)
+	for i := 0; i < 3; i++ {
+		bucket.Allow()
+	}
+	if actualValue := bucket.Allow(); actualValue != false {
+		t.Errorf("Got %v expected %v", actualValue, false)
+	}
+}
+
+func TestRateLimitedPublisher(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Time{})
+	bus := NewGoEventBus[TestEvent]()
+	publisher := bus.GetPublisherByConfig("event.common", "default", &PublisherConfig{
+		Sync:      true,
+		RateLimit: &RateLimitConfig{Rate: 1, Burst: 2, Clock: fakeClock},
+	}).(*GoPublisher[TestEvent])
+	handler := &countingHandler{}
+	publisher.AddHandler(handler)
+
+	for i := 0; i < 3; i++ {
+		publisher.Offer(TestEvent{AbstractEvent{Source: i}})
+	}
+	if actualValue := handler.count; actualValue != 2 {
+		t.Errorf("Got %v expected %v", actualValue, 2)
+	}
+	fakeClock.Advance(time.Second)
+	if actualValue := publisher.Offer(TestEvent{}); actualValue != true {
+		t.Errorf("Got %v expected %v", actualValue, true)
+	}
+
+	// the stricter publisher limit delays the offer until a token is available
+	publisher.SetRateLimit(&RateLimitConfig{Rate: 0.5, Mode: RateLimitDelay, MaxDelay: 5 * time.Second, Clock: fakeClock})
+	fakeClock.Advance(2 * time.Second)
+	publisher.Offer(TestEvent{})
+	done := make(chan bool)
+	go func() {
+		done <- publisher.Offer(TestEvent{})
+	}()
+	fakeClock.BlockUntil(1)
+	select {
+	case <-done:
+		t.Fatalf("offer returned before the clock advanced")
+	default:
+	}
+	fakeClock.Advance(2 * time.Second)
+	if actualValue := <-done; actualValue != true {
+		t.Errorf("Got %v expected %v", actualValue, true)
+	}
+	if actualValue := publisher.OfferWithTimeout(TestEvent{}, time.Second); actualValue != false {
+		t.Errorf("Got %v expected %v", actualValue, false)
+	}
+	if actualValue := handler.count; actualValue != 5 {
+		t.Errorf("Got %v expected %v", actualValue, 5)
+	}
+}
+
+func TestRateLimitRefund(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Time{})
+	bus := NewGoEventBus[TestEvent]()
+	publisher := bus.GetPublisherByConfig("event.refund", "default", &PublisherConfig{
+		Capacity:  1,
+		RateLimit: &RateLimitConfig{Rate: 1, Burst: 2, Clock: fakeClock},
+	}).(*GoPublisher[TestEvent])
+
+	if actualValue := publisher.Offer(TestEvent{}); actualValue != true {
+		t.Errorf("Got %v expected %v", actualValue, true)
+	}
+	// the full queue rejects the offer, its token is given back
+	if actualValue := publisher.Offer(TestEvent{}); actualValue != false {
+		t.Errorf("Got %v expected %v", actualValue, false)
+	}
+	if actualValue := publisher.OfferWithTimeout(TestEvent{}, 0); actualValue != false {
+		t.Errorf("Got %v expected %v", actualValue, false)
+	}
+	if actualValue := publisher.Group.limiter.bucket.Allow(); actualValue != true {
+		t.Errorf("Got %v expected %v", actualValue, true)
+	}
+}
@@ -0,0 +1,367 @@
+// Package saga coordinates long-running workflows on the event bus.
+//
+// A saga instance is correlated to the events by an id. It reacts to the events, issues commands onto the
+// bus and schedules timeout events with a task.TimeScheduler. Its state and the deadlines of its timeouts
+// are persisted in a Store as JSON, the timeouts are scheduled again when a manager starts.
+package saga
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+	"github.com/meshware/suit-kit-golang/pkg/encoding/json"
+	"github.com/meshware/suit-kit-golang/pkg/event"
+	"github.com/meshware/suit-kit-golang/pkg/task"
+)
+
+// Definition declares how a saga reacts to the events
+type Definition[T event.Event, S any] struct {
+	Name string
+	// Topics are the patterns of the publishers the saga subscribes to on the bus, e.g. "order.#"
+	Topics []string
+	// Correlate returns the id of the saga instance concerned by the event, false to ignore the event
+	Correlate func(event T) (string, bool)
+	// Starts reports whether the event starts a new instance when none is correlated, nil starts on any event
+	Starts func(event T) bool
+	// Handle reacts to the event, the state is saved only if no error is returned
+	Handle func(ctx *Context[T, S], event T) error
+}
+
+type command[T event.Event] struct {
+	group, name string
+	event       T
+}
+
+type timeout[T event.Event] struct {
+	name  string
+	delay time.Duration
+	event T
+}
+
+// Context gives access to the state of a saga instance while it handles an event
+type Context[T event.Event, S any] struct {
+	CorrelationID string
+	State         *S
+	// Started reports whether the instance is created by this event
+	Started   bool
+	completed bool
+	commands  []command[T]
+	timeouts  []timeout[T]
+	cancels   []string
+}
+
+// Send offers the command to the publisher of the bus once the state is saved
+func (c *Context[T, S]) Send(group, name string, cmd T) {
+	c.commands = append(c.commands, command[T]{group: group, name: name, event: cmd})
+}
+
+// ScheduleTimeout delivers the event to the instance after the delay, unless the timeout is cancelled or
+// the instance completes. Scheduling an existing name replaces it.
+func (c *Context[T, S]) ScheduleTimeout(name string, delay time.Duration, timeoutEvent T) {
+	c.timeouts = append(c.timeouts, timeout[T]{name: name, delay: delay, event: timeoutEvent})
+}
+
+// CancelTimeout cancels the timeout scheduled with the name
+func (c *Context[T, S]) CancelTimeout(name string) {
+	c.cancels = append(c.cancels, name)
+}
+
+// Complete ends the instance, its pending timeouts are cancelled and the following events are ignored
+func (c *Context[T, S]) Complete() {
+	c.completed = true
+}
+
+// Manager routes the events of the bus to the saga instances
+type Manager[T event.Event, S any] struct {
+	definition Definition[T, S]
+	bus        *event.GoEventBus[T]
+	scheduler  *task.TimeScheduler
+	store      Store
+	// timeouts are the scheduled timeouts by correlation id and name
+	timeouts map[string]map[string]task.Timeout
+	// locks serialize the events of an instance
+	locks map[string]*instanceLock
+	// OnError receives the errors of the events delivered by the bus or the scheduler
+	OnError func(correlationID string, event T, err error)
+	// Clock dates the instances and the deadlines of their timeouts, the real clock by default
+	Clock clock.Clock
+	mu    sync.Mutex
+}
+
+type instanceLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+var _ event.FallibleEventHandler[event.AbstractEvent] = &Manager[event.AbstractEvent, struct{}]{}
+
+// NewManager creates the process manager of the saga, the scheduler runs its timeouts and the store
+// defaults to a MemoryStore
+func NewManager[T event.Event, S any](definition Definition[T, S], bus *event.GoEventBus[T], scheduler *task.TimeScheduler,
+	store Store) (*Manager[T, S], error) {
+	if len(definition.Name) == 0 || definition.Correlate == nil || definition.Handle == nil {
+		return nil, fmt.Errorf("saga: name, correlate and handle are required")
+	}
+	if bus == nil {
+		return nil, fmt.Errorf("saga: nil bus")
+	}
+	if scheduler == nil {
+		return nil, fmt.Errorf("saga: nil scheduler")
+	}
+	for _, topic := range definition.Topics {
+		if err := event.ValidateTopicPattern(topic); err != nil {
+			return nil, err
+		}
+	}
+	if store == nil {
+		store = NewMemoryStore()
+	}
+	return &Manager[T, S]{
+		definition: definition,
+		bus:        bus,
+		scheduler:  scheduler,
+		store:      store,
+		timeouts:   make(map[string]map[string]task.Timeout),
+		locks:      make(map[string]*instanceLock),
+	}, nil
+}
+
+// Start schedules the persisted timeouts of the instances and subscribes the manager to the topics of the saga
+func (m *Manager[T, S]) Start(ctx context.Context) error {
+	if err := m.rearm(); err != nil {
+		return err
+	}
+	for _, topic := range m.definition.Topics {
+		if err := m.bus.Subscribe(topic, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop unsubscribes the manager and cancels the scheduled timeouts, the instances and their deadlines stay
+// in the store
+func (m *Manager[T, S]) Stop(ctx context.Context) error {
+	for _, topic := range m.definition.Topics {
+		m.bus.Unsubscribe(topic, m)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for correlationID := range m.timeouts {
+		m.cancelTimeouts(correlationID)
+	}
+	return nil
+}
+
+func (m *Manager[T, S]) Handler(e T) {
+	_ = m.TryHandler(e)
+}
+
+// TryHandler delivers the event to the correlated instance
+func (m *Manager[T, S]) TryHandler(e T) error {
+	correlationID, ok := m.definition.Correlate(e)
+	if !ok {
+		return nil
+	}
+	return m.deliver(correlationID, e, nil)
+}
+
+// Instance returns the persisted instance, nil if absent
+func (m *Manager[T, S]) Instance(correlationID string) (*Instance, error) {
+	return m.store.Load(m.definition.Name, correlationID)
+}
+
+// deliver handles the event, fired is the deadline of the timeout delivering it
+func (m *Manager[T, S]) deliver(correlationID string, e T, fired *Deadline) error {
+	err := m.handle(correlationID, e, fired)
+	if err != nil && m.OnError != nil {
+		m.OnError(correlationID, e, err)
+	}
+	return err
+}
+
+func (m *Manager[T, S]) handle(correlationID string, e T, fired *Deadline) error {
+	ctx, err := m.update(correlationID, e, fired)
+	if err != nil || ctx == nil {
+		return err
+	}
+	for _, cmd := range ctx.commands {
+		publisher := m.bus.GetPublisher(cmd.group, cmd.name)
+		if publisher == nil || !publisher.Offer(cmd.event) {
+			return fmt.Errorf("saga: command of %s/%s rejected by %s/%s",
+				m.definition.Name, correlationID, cmd.group, cmd.name)
+		}
+	}
+	return nil
+}
+
+// update handles the event and saves the instance, it returns nil if the event is ignored
+func (m *Manager[T, S]) update(correlationID string, e T, fired *Deadline) (*Context[T, S], error) {
+	unlock := m.lock(correlationID)
+	defer unlock()
+	instance, err := m.store.Load(m.definition.Name, correlationID)
+	if err != nil {
+		return nil, err
+	}
+	ctx := &Context[T, S]{CorrelationID: correlationID, State: new(S)}
+	if fired != nil {
+		// the timeout was cancelled or replaced while it fired
+		if instance == nil || instance.Completed || !instance.remove(*fired) {
+			return nil, nil
+		}
+	}
+	if instance == nil {
+		if m.definition.Starts != nil && !m.definition.Starts(e) {
+			return nil, nil
+		}
+		instance = &Instance{Saga: m.definition.Name, CorrelationID: correlationID}
+		ctx.Started = true
+	} else if instance.Completed {
+		return nil, nil
+	} else if err = json.UnmarshalJSON(instance.State, ctx.State); err != nil {
+		return nil, fmt.Errorf("saga: state of %s/%s: %w", m.definition.Name, correlationID, err)
+	}
+	if err = m.definition.Handle(ctx, e); err != nil {
+		return nil, err
+	}
+	if instance.State, err = json.MarshalJSON(ctx.State); err != nil {
+		return nil, err
+	}
+	now := clock.OrReal(m.Clock).Now()
+	scheduled, err := m.deadlines(instance, ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	instance.Completed = ctx.completed
+	instance.Version++
+	instance.UpdatedAt = now
+	if err = m.store.Save(instance); err != nil {
+		return nil, err
+	}
+	m.applyTimeouts(ctx, scheduled)
+	return ctx, nil
+}
+
+// deadlines applies the timeouts of the context to the deadlines of the instance, it returns the new ones
+func (m *Manager[T, S]) deadlines(instance *Instance, ctx *Context[T, S], now time.Time) ([]Deadline, error) {
+	if ctx.completed {
+		instance.Deadlines = nil
+		return nil, nil
+	}
+	for _, name := range ctx.cancels {
+		instance.cancel(name)
+	}
+	scheduled := make([]Deadline, 0, len(ctx.timeouts))
+	for _, to := range ctx.timeouts {
+		data, err := json.MarshalJSON(to.event)
+		if err != nil {
+			return nil, fmt.Errorf("saga: timeout %s of %s/%s: %w", to.name, m.definition.Name, ctx.CorrelationID, err)
+		}
+		deadline := Deadline{Name: to.name, At: now.Add(to.delay), Event: data}
+		instance.cancel(to.name)
+		instance.Deadlines = append(instance.Deadlines, deadline)
+		scheduled = append(scheduled, deadline)
+	}
+	return scheduled, nil
+}
+
+func (m *Manager[T, S]) applyTimeouts(ctx *Context[T, S], scheduled []Deadline) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	correlationID := ctx.CorrelationID
+	if ctx.completed {
+		m.cancelTimeouts(correlationID)
+		return
+	}
+	pending := m.timeouts[correlationID]
+	for _, name := range ctx.cancels {
+		if t, ok := pending[name]; ok {
+			t.Cancel()
+			delete(pending, name)
+		}
+	}
+	for i, deadline := range scheduled {
+		m.arm(correlationID, deadline, ctx.timeouts[i].event)
+	}
+}
+
+// rearm schedules the persisted deadlines of the instances
+func (m *Manager[T, S]) rearm() error {
+	instances, err := m.store.List(m.definition.Name)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, instance := range instances {
+		if instance.Completed {
+			continue
+		}
+		for _, deadline := range instance.Deadlines {
+			var e T
+			if err = json.UnmarshalJSON(deadline.Event, &e); err != nil {
+				return fmt.Errorf("saga: timeout %s of %s/%s: %w", deadline.Name, m.definition.Name,
+					instance.CorrelationID, err)
+			}
+			m.arm(instance.CorrelationID, deadline, e)
+		}
+	}
+	return nil
+}
+
+// arm schedules the timeout at its deadline, replacing the one with the same name
+func (m *Manager[T, S]) arm(correlationID string, deadline Deadline, e T) {
+	pending := m.timeouts[correlationID]
+	if pending == nil {
+		pending = make(map[string]task.Timeout)
+		m.timeouts[correlationID] = pending
+	}
+	if t, ok := pending[deadline.Name]; ok {
+		t.Cancel()
+	}
+	delay := deadline.At.Sub(clock.OrReal(m.Clock).Now()).Milliseconds()
+	if delay < 0 {
+		delay = 0
+	}
+	var scheduled task.Timeout
+	scheduled = m.scheduler.Delay(m.definition.Name+"/"+correlationID+"/"+deadline.Name, delay, func() {
+		m.mu.Lock()
+		if current := m.timeouts[correlationID]; current[deadline.Name] == scheduled {
+			delete(current, deadline.Name)
+		}
+		m.mu.Unlock()
+		_ = m.deliver(correlationID, e, &deadline)
+	})
+	pending[deadline.Name] = scheduled
+}
+
+func (m *Manager[T, S]) cancelTimeouts(correlationID string) {
+	for _, t := range m.timeouts[correlationID] {
+		t.Cancel()
+	}
+	delete(m.timeouts, correlationID)
+}
+
+// lock locks the instance and returns the function unlocking it
+func (m *Manager[T, S]) lock(correlationID string) func() {
+	m.mu.Lock()
+	l, ok := m.locks[correlationID]
+	if !ok {
+		l = &instanceLock{}
+		m.locks[correlationID] = l
+	}
+	l.refs++
+	m.mu.Unlock()
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+		m.mu.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(m.locks, correlationID)
+		}
+		m.mu.Unlock()
+	}
+}
@@ -0,0 +1,160 @@
+package saga
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+	"github.com/meshware/suit-kit-golang/pkg/event"
+	"github.com/meshware/suit-kit-golang/pkg/event/eventtest"
+	"github.com/meshware/suit-kit-golang/pkg/task"
+)
+
+type OrderEvent struct {
+	event.AbstractEvent
+	Type    string
+	OrderID string
+}
+
+type orderState struct {
+	Placed  bool
+	Paid    bool
+	Expired bool
+}
+
+func newOrderEvent(eventType, orderID string) OrderEvent {
+	return OrderEvent{Type: eventType, OrderID: orderID}
+}
+
+func newOrderSaga(t *testing.T, fakeClock *clock.FakeClock, store Store) (*Manager[OrderEvent, orderState],
+	*event.GoEventBus[OrderEvent], *task.TimeScheduler) {
+	bus := eventtest.NewSyncBus[OrderEvent]()
+	scheduler := task.NewTimeScheduler("saga", 10, 100, 2, task.WithClock(fakeClock))
+	scheduler.Start(context.Background())
+	manager, err := NewManager(Definition[OrderEvent, orderState]{
+		Name:   "order",
+		Topics: []string{"order.*"},
+		Correlate: func(e OrderEvent) (string, bool) {
+			return e.OrderID, len(e.OrderID) > 0
+		},
+		Starts: func(e OrderEvent) bool {
+			return e.Type == "placed"
+		},
+		Handle: func(ctx *Context[OrderEvent, orderState], e OrderEvent) error {
+			switch e.Type {
+			case "placed":
+				ctx.State.Placed = true
+				ctx.Send("payment", "request", newOrderEvent("request", e.OrderID))
+				ctx.ScheduleTimeout("payment", 50*time.Millisecond, newOrderEvent("expired", e.OrderID))
+			case "paid":
+				ctx.State.Paid = true
+				ctx.CancelTimeout("payment")
+				ctx.Complete()
+			case "expired":
+				ctx.State.Expired = true
+				ctx.Send("order", "cancel", newOrderEvent("cancel", e.OrderID))
+				ctx.Complete()
+			}
+			return nil
+		},
+	}, bus, scheduler, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manager.Clock = fakeClock
+	if err = manager.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return manager, bus, scheduler
+}
+
+func TestSaga(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	manager, bus, scheduler := newOrderSaga(t, fakeClock, nil)
+	defer scheduler.Close()
+	defer manager.Stop(context.Background())
+	requests := eventtest.NewRecordingHandler[OrderEvent]()
+	bus.GetPublisher("payment", "request").AddHandler(requests)
+	cancels := eventtest.NewRecordingHandler[OrderEvent]()
+	bus.GetPublisher("order", "cancel").AddHandler(cancels)
+
+	bus.GetPublisher("order", "paid").Offer(newOrderEvent("paid", "0"))
+	if instance, _ := manager.Instance("0"); instance != nil {
+		t.Errorf("Got %v expected %v", instance, nil)
+	}
+
+	bus.GetPublisher("order", "placed").Offer(newOrderEvent("placed", "1"))
+	bus.GetPublisher("order", "placed").Offer(newOrderEvent("placed", "2"))
+	requests.AwaitEvents(t, 2, time.Second)
+	bus.GetPublisher("order", "paid").Offer(newOrderEvent("paid", "1"))
+	if _, ok := scheduler.Get("order/1/payment"); ok {
+		t.Errorf("Got %v expected %v", ok, false)
+	}
+	pending, _ := manager.Instance("2")
+	if len(pending.Deadlines) != 1 || !pending.Deadlines[0].At.Equal(time.UnixMilli(50)) {
+		t.Errorf("Got %v expected the payment deadline", pending.Deadlines)
+	}
+
+	fakeClock.Advance(50 * time.Millisecond)
+	cancelled := cancels.AwaitEvents(t, 1, time.Second)
+	if actualValue := cancelled[0].OrderID; actualValue != "2" {
+		t.Errorf("Got %v expected %v", actualValue, "2")
+	}
+
+	paid, _ := manager.Instance("1")
+	if paid == nil || !paid.Completed || paid.Version != 2 {
+		t.Errorf("Got %v expected a completed instance at version 2", paid)
+	}
+	expired, _ := manager.Instance("2")
+	if expired == nil || !expired.Completed || len(expired.Deadlines) != 0 || !expired.UpdatedAt.Equal(time.UnixMilli(50)) {
+		t.Errorf("Got %v expected a completed instance", expired)
+	}
+	bus.GetPublisher("order", "paid").Offer(newOrderEvent("paid", "2"))
+	if again, _ := manager.Instance("2"); again.Version != expired.Version {
+		t.Errorf("Got %v expected %v", again.Version, expired.Version)
+	}
+}
+
+func TestSagaRestart(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	store := NewMemoryStore()
+	manager, bus, scheduler := newOrderSaga(t, fakeClock, store)
+	defer scheduler.Close()
+	bus.GetPublisher("order", "placed").Offer(newOrderEvent("placed", "3"))
+	_ = manager.Stop(context.Background())
+	if _, ok := scheduler.Get("order/3/payment"); ok {
+		t.Errorf("Got %v expected %v", ok, false)
+	}
+
+	// the persisted deadline is scheduled again by the next manager
+	restarted, bus, rescheduler := newOrderSaga(t, fakeClock, store)
+	defer rescheduler.Close()
+	defer restarted.Stop(context.Background())
+	cancels := eventtest.NewRecordingHandler[OrderEvent]()
+	bus.GetPublisher("order", "cancel").AddHandler(cancels)
+	if _, ok := rescheduler.Get("order/3/payment"); !ok {
+		t.Errorf("Got %v expected %v", ok, true)
+	}
+	fakeClock.Advance(50 * time.Millisecond)
+	cancelled := cancels.AwaitEvents(t, 1, time.Second)
+	if actualValue := cancelled[0].OrderID; actualValue != "3" {
+		t.Errorf("Got %v expected %v", actualValue, "3")
+	}
+}
+
+func TestNewManagerNilScheduler(t *testing.T) {
+	// the timeouts of the saga would never fire without a scheduler
+	_, err := NewManager(Definition[OrderEvent, orderState]{
+		Name: "order",
+		Correlate: func(e OrderEvent) (string, bool) {
+			return e.OrderID, true
+		},
+		Handle: func(ctx *Context[OrderEvent, orderState], e OrderEvent) error {
+			return nil
+		},
+	}, eventtest.NewSyncBus[OrderEvent](), nil, nil)
+	if err == nil {
+		t.Errorf("Got %v expected an error", err)
+	}
+}
@@ -0,0 +1,117 @@
+package saga
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Instance the persisted state of a saga instance
+type Instance struct {
+	Saga          string    `json:"saga"`
+	CorrelationID string    `json:"correlationId"`
+	State         []byte    `json:"state"`
+	Completed     bool      `json:"completed"`
+	Version       uint64    `json:"version"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+	// Deadlines are the pending timeouts of the instance
+	Deadlines []Deadline `json:"deadlines,omitempty"`
+}
+
+// Deadline a pending timeout of a saga instance
+type Deadline struct {
+	Name string    `json:"name"`
+	At   time.Time `json:"at"`
+	// Event is the JSON of the event delivered at the deadline
+	Event []byte `json:"event"`
+}
+
+// cancel removes the deadline with the name
+func (i *Instance) cancel(name string) {
+	for index, deadline := range i.Deadlines {
+		if deadline.Name == name {
+			i.Deadlines = append(i.Deadlines[:index:index], i.Deadlines[index+1:]...)
+			return
+		}
+	}
+}
+
+// remove removes the deadline, it returns false if the instance does not have it
+func (i *Instance) remove(deadline Deadline) bool {
+	for _, pending := range i.Deadlines {
+		if pending.Name == deadline.Name && pending.At.Equal(deadline.At) {
+			i.cancel(deadline.Name)
+			return true
+		}
+	}
+	return false
+}
+
+// Store persists the saga instances
+type Store interface {
+	// Load returns the instance, nil if absent
+	Load(saga, correlationID string) (*Instance, error)
+	// List returns the instances of the saga
+	List(saga string) ([]*Instance, error)
+	Save(instance *Instance) error
+	Delete(saga, correlationID string) error
+}
+
+// MemoryStore a Store keeping the instances in memory
+type MemoryStore struct {
+	instances map[string]Instance
+	mu        sync.RWMutex
+}
+
+var _ Store = &MemoryStore{}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{instances: make(map[string]Instance)}
+}
+
+func (ms *MemoryStore) Load(saga, correlationID string) (*Instance, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	instance, ok := ms.instances[saga+"/"+correlationID]
+	if !ok {
+		return nil, nil
+	}
+	return copyInstance(instance), nil
+}
+
+func (ms *MemoryStore) List(saga string) ([]*Instance, error) {
+	ms.mu.RLock()
+	defer ms.mu.RUnlock()
+	var instances []*Instance
+	for key, instance := range ms.instances {
+		if strings.HasPrefix(key, saga+"/") && instance.Saga == saga {
+			instances = append(instances, copyInstance(instance))
+		}
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].CorrelationID < instances[j].CorrelationID
+	})
+	return instances, nil
+}
+
+func (ms *MemoryStore) Save(instance *Instance) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	ms.instances[instance.Saga+"/"+instance.CorrelationID] = *copyInstance(*instance)
+	return nil
+}
+
+func (ms *MemoryStore) Delete(saga, correlationID string) error {
+	ms.mu.Lock()
+	defer ms.mu.Unlock()
+	delete(ms.instances, saga+"/"+correlationID)
+	return nil
+}
+
+// copyInstance copies the instance with its state and deadlines
+func copyInstance(instance Instance) *Instance {
+	instance.State = append([]byte(nil), instance.State...)
+	instance.Deadlines = append([]Deadline(nil), instance.Deadlines...)
+	return &instance
+}
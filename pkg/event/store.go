@@ -0,0 +1,277 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+var (
+	// ErrConcurrencyConflict the version of the stream is not the expected one
+	ErrConcurrencyConflict = errors.New("event: concurrency conflict")
+	// ErrNotPublished the events are appended but some could not be offered to the publisher
+	ErrNotPublished = errors.New("event: appended events not published")
+	// ErrSnapshotFailed the events are appended but the snapshot could not be taken
+	ErrSnapshotFailed = errors.New("event: snapshot failed")
+)
+
+const (
+	// AnyVersion appends to the stream whatever its version
+	AnyVersion int64 = -1
+	// NoStream appends only if the stream has no events yet
+	NoStream int64 = 0
+)
+
+// RecordedEvent an event appended to a stream, versions start at 1
+type RecordedEvent[T Event] struct {
+	StreamID string
+	Version  uint64
+	Time     time.Time
+	Event    T
+}
+
+// Snapshot the state of an aggregate folded from the events of its stream up to the version
+type Snapshot struct {
+	StreamID string    `json:"streamId"`
+	Version  uint64    `json:"version"`
+	State    []byte    `json:"state"`
+	Time     time.Time `json:"time"`
+}
+
+// Snapshotter folds the events appended after the previous snapshot, nil for the first one, into the new state
+type Snapshotter[T Event] func(previous *Snapshot, events []RecordedEvent[T]) ([]byte, error)
+
+// EventStore appends events to streams with optimistic concurrency
+type EventStore[T Event] interface {
+	// Append adds the events to the stream if its version is the expected one, and returns the new version
+	Append(streamID string, expectedVersion int64, events ...T) (uint64, error)
+	// Read returns at most count events of the stream from the version on, count <= 0 means all
+	Read(streamID string, fromVersion uint64, count int) ([]RecordedEvent[T], error)
+	Version(streamID string) (uint64, error)
+	// Snapshot returns the latest snapshot of the stream, nil if none
+	Snapshot(streamID string) (*Snapshot, error)
+	SaveSnapshot(snapshot *Snapshot) error
+}
+
+// StoreOptions Configure the publishing and snapshots of an event store
+type StoreOptions[T Event] struct {
+	// Publisher receives the appended events if set
+	Publisher Publisher[T]
+	// SnapshotEvery takes a snapshot with the Snapshotter once as many events are appended since the latest one
+	SnapshotEvery uint64
+	Snapshotter   Snapshotter[T]
+	Clock         clock.Clock
+}
+
+// storeBackend persists the streams cached by GoEventStore
+type storeBackend[T Event] interface {
+	load(streamID string) ([]RecordedEvent[T], *Snapshot, error)
+	append(streamID string, records []RecordedEvent[T]) error
+	saveSnapshot(snapshot *Snapshot) error
+}
+
+type memoryBackend[T Event] struct{}
+
+func (memoryBackend[T]) load(streamID string) ([]RecordedEvent[T], *Snapshot, error) {
+	return nil, nil, nil
+}
+
+func (memoryBackend[T]) append(streamID string, records []RecordedEvent[T]) error {
+	return nil
+}
+
+func (memoryBackend[T]) saveSnapshot(snapshot *Snapshot) error {
+	return nil
+}
+
+type eventStream[T Event] struct {
+	records  []RecordedEvent[T]
+	snapshot *Snapshot
+}
+
+// GoEventStore an EventStore keeping the streams in memory, optionally backed by files
+type GoEventStore[T Event] struct {
+	options StoreOptions[T]
+	backend storeBackend[T]
+	streams map[string]*eventStream[T]
+	mu      sync.Mutex
+}
+
+var _ EventStore[AbstractEvent] = &GoEventStore[AbstractEvent]{}
+
+func NewMemoryEventStore[T Event](options *StoreOptions[T]) *GoEventStore[T] {
+	return newGoEventStore[T](options, memoryBackend[T]{})
+}
+
+func newGoEventStore[T Event](options *StoreOptions[T], backend storeBackend[T]) *GoEventStore[T] {
+	if options == nil {
+		options = &StoreOptions[T]{}
+	}
+	resolved := *options
+	resolved.Clock = clock.OrReal(resolved.Clock)
+	return &GoEventStore[T]{
+		options: resolved,
+		backend: backend,
+		streams: make(map[string]*eventStream[T]),
+	}
+}
+
+func (es *GoEventStore[T]) stream(streamID string) (*eventStream[T], error) {
+	if len(streamID) == 0 {
+		return nil, fmt.Errorf("event: empty stream id")
+	}
+	stream, ok := es.streams[streamID]
+	if !ok {
+		records, snapshot, err := es.backend.load(streamID)
+		if err != nil {
+			return nil, err
+		}
+		stream = &eventStream[T]{records: records, snapshot: snapshot}
+		es.streams[streamID] = stream
+	}
+	return stream, nil
+}
+
+func (es *GoEventStore[T]) Append(streamID string, expectedVersion int64, events ...T) (uint64, error) {
+	es.mu.Lock()
+	stream, err := es.stream(streamID)
+	if err != nil {
+		es.mu.Unlock()
+		return 0, err
+	}
+	version := uint64(len(stream.records))
+	if expectedVersion != AnyVersion && (expectedVersion < 0 || uint64(expectedVersion) != version) {
+		es.mu.Unlock()
+		return version, fmt.Errorf("%w: stream %s is at version %d, expected %d",
+			ErrConcurrencyConflict, streamID, version, expectedVersion)
+	}
+	if len(events) == 0 {
+		es.mu.Unlock()
+		return version, nil
+	}
+	now := es.options.Clock.Now()
+	records := make([]RecordedEvent[T], len(events))
+	for i, event := range events {
+		records[i] = RecordedEvent[T]{
+			StreamID: streamID,
+			Version:  version + uint64(i) + 1,
+			Time:     now,
+			Event:    event,
+		}
+	}
+	if err = es.backend.append(streamID, records); err != nil {
+		es.mu.Unlock()
+		return version, err
+	}
+	stream.records = append(stream.records, records...)
+	version = uint64(len(stream.records))
+	err = es.snapshotIfDue(stream, streamID)
+	es.mu.Unlock()
+
+	if publisher := es.options.Publisher; publisher != nil {
+		failed := 0
+		for _, record := range records {
+			if !publisher.Offer(record.Event) {
+				failed++
+			}
+		}
+		if failed > 0 && err == nil {
+			err = fmt.Errorf("%w: %d of %d events of stream %s", ErrNotPublished, failed, len(records), streamID)
+		}
+	}
+	return version, err
+}
+
+func (es *GoEventStore[T]) snapshotIfDue(stream *eventStream[T], streamID string) error {
+	if es.options.SnapshotEvery == 0 || es.options.Snapshotter == nil {
+		return nil
+	}
+	from := uint64(0)
+	if stream.snapshot != nil {
+		from = stream.snapshot.Version
+	}
+	version := uint64(len(stream.records))
+	if version-from < es.options.SnapshotEvery {
+		return nil
+	}
+	state, err := es.options.Snapshotter(stream.snapshot, stream.records[from:])
+	if err == nil {
+		snapshot := &Snapshot{StreamID: streamID, Version: version, State: state, Time: es.options.Clock.Now()}
+		if err = es.backend.saveSnapshot(snapshot); err == nil {
+			stream.snapshot = snapshot
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: stream %s at version %d: %v", ErrSnapshotFailed, streamID, version, err)
+}
+
+func (es *GoEventStore[T]) Read(streamID string, fromVersion uint64, count int) ([]RecordedEvent[T], error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	stream, err := es.stream(streamID)
+	if err != nil {
+		return nil, err
+	}
+	if fromVersion > 0 {
+		fromVersion--
+	}
+	if fromVersion >= uint64(len(stream.records)) {
+		return nil, nil
+	}
+	records := stream.records[fromVersion:]
+	if count > 0 && count < len(records) {
+		records = records[:count]
+	}
+	return append([]RecordedEvent[T](nil), records...), nil
+}
+
+func (es *GoEventStore[T]) Version(streamID string) (uint64, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	stream, err := es.stream(streamID)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(stream.records)), nil
+}
+
+func (es *GoEventStore[T]) Snapshot(streamID string) (*Snapshot, error) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	stream, err := es.stream(streamID)
+	if err != nil {
+		return nil, err
+	}
+	return stream.snapshot, nil
+}
+
+// SaveSnapshot keeps the snapshot if it is not older than the latest one
+func (es *GoEventStore[T]) SaveSnapshot(snapshot *Snapshot) error {
+	if snapshot == nil {
+		return fmt.Errorf("event: nil snapshot")
+	}
+	es.mu.Lock()
+	defer es.mu.Unlock()
+	stream, err := es.stream(snapshot.StreamID)
+	if err != nil {
+		return err
+	}
+	if snapshot.Version > uint64(len(stream.records)) {
+		return fmt.Errorf("event: snapshot version %d beyond stream %s version %d",
+			snapshot.Version, snapshot.StreamID, len(stream.records))
+	}
+	if stream.snapshot != nil && stream.snapshot.Version > snapshot.Version {
+		return nil
+	}
+	if snapshot.Time.IsZero() {
+		snapshot.Time = es.options.Clock.Now()
+	}
+	if err = es.backend.saveSnapshot(snapshot); err != nil {
+		return err
+	}
+	stream.snapshot = snapshot
+	return nil
+}
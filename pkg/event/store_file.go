@@ -0,0 +1,151 @@
+package event
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/encoding/json"
+)
+
+const (
+	streamFileSuffix   = ".events.jsonl"
+	snapshotFileSuffix = ".snapshot.json"
+)
+
+// fileRecord a line of a stream file
+type fileRecord struct {
+	Version uint64          `json:"version"`
+	Time    time.Time       `json:"time"`
+	Event   json.RawMessage `json:"event"`
+}
+
+// fileBackend keeps each stream in a JSON lines file of the directory, and its latest snapshot aside
+type fileBackend[T Event] struct {
+	dir      string
+	registry *CodecRegistry
+}
+
+// NewFileEventStore creates an event store persisting the streams in the directory, the events are
+// encoded by the registry which must use the JSON codec. The directory is not locked, it must be used
+// by a single store of a single process.
+func NewFileEventStore[T Event](dir string, registry *CodecRegistry, options *StoreOptions[T]) (*GoEventStore[T], error) {
+	if registry == nil || registry.Codec() != JSONCodec {
+		return nil, fmt.Errorf("event: file event store needs a JSON codec registry")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return newGoEventStore[T](options, &fileBackend[T]{dir: dir, registry: registry}), nil
+}
+
+func (fb *fileBackend[T]) path(streamID, suffix string) string {
+	return filepath.Join(fb.dir, url.PathEscape(streamID)+suffix)
+}
+
+func (fb *fileBackend[T]) load(streamID string) ([]RecordedEvent[T], *Snapshot, error) {
+	records, err := fb.loadRecords(streamID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var snapshot *Snapshot
+	data, err := os.ReadFile(fb.path(streamID, snapshotFileSuffix))
+	if err == nil {
+		snapshot = &Snapshot{}
+		if err = json.UnmarshalJSON(data, snapshot); err != nil {
+			return nil, nil, fmt.Errorf("event: snapshot of stream %s: %w", streamID, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, err
+	}
+	return records, snapshot, nil
+}
+
+func (fb *fileBackend[T]) loadRecords(streamID string) ([]RecordedEvent[T], error) {
+	path := fb.path(streamID, streamFileSuffix)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	// a crash while appending leaves an incomplete last record, it is truncated so that the next append
+	// starts on its own line
+	if complete := bytes.LastIndexByte(data, '\n') + 1; complete < len(data) {
+		if err = os.Truncate(path, int64(complete)); err != nil {
+			return nil, err
+		}
+		data = data[:complete]
+	}
+	var records []RecordedEvent[T]
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		record := fileRecord{}
+		if err = json.UnmarshalJSON(line, &record); err != nil {
+			return nil, fmt.Errorf("event: stream %s line %d: %w", streamID, len(records)+1, err)
+		}
+		if record.Version != uint64(len(records))+1 {
+			return nil, fmt.Errorf("event: stream %s has version %d at line %d", streamID, record.Version, len(records)+1)
+		}
+		event, err := DecodeAs[T](fb.registry, record.Event)
+		if err != nil {
+			return nil, fmt.Errorf("event: stream %s version %d: %w", streamID, record.Version, err)
+		}
+		records = append(records, RecordedEvent[T]{
+			StreamID: streamID,
+			Version:  record.Version,
+			Time:     record.Time,
+			Event:    event,
+		})
+	}
+	return records, scanner.Err()
+}
+
+func (fb *fileBackend[T]) append(streamID string, records []RecordedEvent[T]) error {
+	buffer := &bytes.Buffer{}
+	for _, record := range records {
+		event, err := fb.registry.Encode(record.Event)
+		if err != nil {
+			return err
+		}
+		line, err := json.MarshalJSON(&fileRecord{Version: record.Version, Time: record.Time, Event: event})
+		if err != nil {
+			return err
+		}
+		buffer.Write(line)
+		buffer.WriteByte('\n')
+	}
+	file, err := os.OpenFile(fb.path(streamID, streamFileSuffix), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(buffer.Bytes()); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func (fb *fileBackend[T]) saveSnapshot(snapshot *Snapshot) error {
+	data, err := json.MarshalJSON(snapshot)
+	if err != nil {
+		return err
+	}
+	path := fb.path(snapshot.StreamID, snapshotFileSuffix)
+	if err = os.WriteFile(path+".tmp", data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(path+".tmp", path)
+}
@@ -0,0 +1,128 @@
+package event
+
+import (
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// sumAmounts folds the amounts of the orders into the state
+func sumAmounts(previous *Snapshot, events []RecordedEvent[*OrderCreated]) ([]byte, error) {
+	total := int64(0)
+	if previous != nil {
+		total, _ = strconv.ParseInt(string(previous.State), 10, 64)
+	}
+	for _, record := range events {
+		total += record.Event.Amount
+	}
+	return []byte(strconv.FormatInt(total, 10)), nil
+}
+
+func TestMemoryEventStore(t *testing.T) {
+	bus := NewGoEventBus[*OrderCreated]()
+	bus.DefaultConfig = &PublisherConfig{Sync: true}
+	publisher := bus.GetPublisher("order", "created")
+	published := 0
+	publisher.AddHandler(&funcHandler[*OrderCreated]{func(event *OrderCreated) { published++ }})
+	store := NewMemoryEventStore[*OrderCreated](&StoreOptions[*OrderCreated]{
+		Publisher:     publisher,
+		SnapshotEvery: 2,
+		Snapshotter:   sumAmounts,
+	})
+
+	version, err := store.Append("order-1", NoStream, &OrderCreated{Amount: 1}, &OrderCreated{Amount: 2})
+	if err != nil || version != 2 {
+		t.Fatalf("Got %v %v expected %v %v", version, err, 2, nil)
+	}
+	if _, err = store.Append("order-1", 1, &OrderCreated{Amount: 3}); !errors.Is(err, ErrConcurrencyConflict) {
+		t.Errorf("Got %v expected %v", err, ErrConcurrencyConflict)
+	}
+	if _, err = store.Append("order-1", 2, &OrderCreated{Amount: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = store.Append("order-1", AnyVersion, &OrderCreated{Amount: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	records, _ := store.Read("order-1", 2, 2)
+	if len(records) != 2 || records[0].Version != 2 || records[1].Event.Amount != 3 {
+		t.Errorf("Got %+v expected versions 2 and 3", records)
+	}
+	snapshot, _ := store.Snapshot("order-1")
+	if snapshot == nil || snapshot.Version != 4 || string(snapshot.State) != "10" {
+		t.Errorf("Got %+v expected version 4 with state 10", snapshot)
+	}
+	if published != 4 {
+		t.Errorf("Got %v expected %v", published, 4)
+	}
+}
+
+func TestFileEventStore(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewCodecRegistry(JSONCodec)
+	_ = registry.Register("order.created", 1, &OrderCreated{})
+	options := &StoreOptions[*OrderCreated]{SnapshotEvery: 2, Snapshotter: sumAmounts}
+	store, err := NewFileEventStore[*OrderCreated](dir, registry, options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := int64(1); i <= 3; i++ {
+		if _, err = store.Append("order/1", int64(i-1), &OrderCreated{OrderID: "order/1", Amount: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, _ := NewFileEventStore[*OrderCreated](dir, registry, options)
+	if version, _ := reopened.Version("order/1"); version != 3 {
+		t.Errorf("Got %v expected %v", version, 3)
+	}
+	records, err := reopened.Read("order/1", 0, 0)
+	if err != nil || len(records) != 3 || records[2].Event.Amount != 3 || records[2].Event.OrderID != "order/1" {
+		t.Errorf("Got %+v %v expected 3 records", records, err)
+	}
+	if snapshot, _ := reopened.Snapshot("order/1"); snapshot == nil || snapshot.Version != 2 || string(snapshot.State) != "3" {
+		t.Errorf("Got %+v expected version 2 with state 3", snapshot)
+	}
+	if _, err = reopened.Append("order/1", 2, &OrderCreated{}); !errors.Is(err, ErrConcurrencyConflict) {
+		t.Errorf("Got %v expected %v", err, ErrConcurrencyConflict)
+	}
+}
+
+type funcHandler[T Event] struct {
+	fn func(event T)
+}
+
+func (fh *funcHandler[T]) Handler(event T) {
+	fh.fn(event)
+}
+
+func TestFileEventStoreTornRecord(t *testing.T) {
+	dir := t.TempDir()
+	registry := NewCodecRegistry(JSONCodec)
+	_ = registry.Register("order.created", 1, &OrderCreated{})
+	store, err := NewFileEventStore[*OrderCreated](dir, registry, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = store.Append("order/1", 0, &OrderCreated{OrderID: "order/1", Amount: 1})
+
+	// a crash while appending leaves an incomplete last record
+	file, _ := os.OpenFile(filepath.Join(dir, url.PathEscape("order/1")+streamFileSuffix), os.O_WRONLY|os.O_APPEND, 0o644)
+	_, _ = file.WriteString(`{"version":2,"time":`)
+	_ = file.Close()
+	reopened, _ := NewFileEventStore[*OrderCreated](dir, registry, nil)
+	if version, err := reopened.Version("order/1"); err != nil || version != 1 {
+		t.Errorf("Got %v %v expected %v", version, err, 1)
+	}
+	if _, err = reopened.Append("order/1", 1, &OrderCreated{OrderID: "order/1", Amount: 2}); err != nil {
+		t.Fatal(err)
+	}
+	reopened, _ = NewFileEventStore[*OrderCreated](dir, registry, nil)
+	records, err := reopened.Read("order/1", 0, 0)
+	if err != nil || len(records) != 2 || records[1].Event.Amount != 2 {
+		t.Errorf("Got %+v %v expected 2 records", records, err)
+	}
+}
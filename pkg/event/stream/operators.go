@@ -0,0 +1,180 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+	"github.com/meshware/suit-kit-golang/pkg/collection"
+	"github.com/meshware/suit-kit-golang/pkg/event"
+)
+
+// Map publishes the events of the upstream converted by the function
+func Map[T, R event.Event](upstream event.Publisher[T], fn func(event T) R) *Stream[R] {
+	s := newStream[R]()
+	connect(s, upstream, func(e T) {
+		s.emit(fn(e))
+	})
+	return s
+}
+
+// Filter publishes the events of the upstream matched by the predicate
+func Filter[T event.Event](upstream event.Publisher[T], predicate event.Predicate[T]) *Stream[T] {
+	s := newStream[T]()
+	connect(s, upstream, func(e T) {
+		if predicate(e) {
+			s.emit(e)
+		}
+	})
+	return s
+}
+
+// Merge publishes the events of all the upstream publishers
+func Merge[T event.Event](upstreams ...event.Publisher[T]) *Stream[T] {
+	s := newStream[T]()
+	for _, upstream := range upstreams {
+		connect(s, upstream, s.emit)
+	}
+	return s
+}
+
+// Zip pairs the events of both upstream publishers in arrival order, and publishes the pairs combined by
+// the function
+func Zip[A, B, R event.Event](left event.Publisher[A], right event.Publisher[B], fn func(a A, b B) R) *Stream[R] {
+	s := newStream[R]()
+	var lefts []A
+	var rights []B
+	var mu sync.Mutex
+	pair := func() (R, bool) {
+		var zero R
+		if len(lefts) == 0 || len(rights) == 0 {
+			return zero, false
+		}
+		a, b := lefts[0], rights[0]
+		lefts, rights = lefts[1:], rights[1:]
+		return fn(a, b), true
+	}
+	connect(s, left, func(e A) {
+		mu.Lock()
+		lefts = append(lefts, e)
+		zipped, ok := pair()
+		mu.Unlock()
+		if ok {
+			s.emit(zipped)
+		}
+	})
+	connect(s, right, func(e B) {
+		mu.Lock()
+		rights = append(rights, e)
+		zipped, ok := pair()
+		mu.Unlock()
+		if ok {
+			s.emit(zipped)
+		}
+	})
+	s.onStop = func() {
+		mu.Lock()
+		defer mu.Unlock()
+		lefts, rights = nil, nil
+	}
+	return s
+}
+
+// Batch the events of the upstream received during a window
+type Batch[T event.Event] struct {
+	event.AbstractEvent
+	Events []T
+	Start  time.Time
+	End    time.Time
+}
+
+// Window publishes the events of the upstream received during each window of the size, empty windows
+// are skipped
+func Window[T event.Event](upstream event.Publisher[T], size time.Duration) *Stream[Batch[T]] {
+	return WindowWithClock(upstream, size, clock.Real)
+}
+
+// WindowWithClock is Window measuring the windows with the clock
+func WindowWithClock[T event.Event](upstream event.Publisher[T], size time.Duration, c clock.Clock) *Stream[Batch[T]] {
+	s := newStream[Batch[T]]()
+	c = clock.OrReal(c)
+	var events []T
+	var start time.Time
+	var stopCh chan struct{}
+	var mu sync.Mutex
+	flush := func() {
+		mu.Lock()
+		batch := Batch[T]{Events: events, Start: start, End: c.Now()}
+		events, start = nil, batch.End
+		mu.Unlock()
+		if len(batch.Events) > 0 {
+			s.emit(batch)
+		}
+	}
+	connect(s, upstream, func(e T) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	})
+	s.onStart = func() {
+		mu.Lock()
+		start = c.Now()
+		stopCh = make(chan struct{})
+		mu.Unlock()
+		go func(stopCh chan struct{}) {
+			for {
+				select {
+				case <-c.After(size):
+					flush()
+				case <-stopCh:
+					return
+				}
+			}
+		}(stopCh)
+	}
+	s.onStop = func() {
+		close(stopCh)
+		flush()
+	}
+	return s
+}
+
+// Distinct publishes the first event of the upstream for each key
+func Distinct[T event.Event, K comparable](upstream event.Publisher[T], key func(event T) K) *Stream[T] {
+	s := newStream[T]()
+	seen := collection.NewSet[K]()
+	var mu sync.Mutex
+	connect(s, upstream, func(e T) {
+		k := key(e)
+		mu.Lock()
+		duplicate := seen.Contains(k)
+		seen.Add(k)
+		mu.Unlock()
+		if !duplicate {
+			s.emit(e)
+		}
+	})
+	return s
+}
+
+// Take publishes the first n events of the upstream, then unsubscribes from it
+func Take[T event.Event](upstream event.Publisher[T], n int) *Stream[T] {
+	s := newStream[T]()
+	taken := 0
+	var mu sync.Mutex
+	connect(s, upstream, func(e T) {
+		mu.Lock()
+		if taken >= n {
+			mu.Unlock()
+			return
+		}
+		taken++
+		done := taken == n
+		mu.Unlock()
+		s.emit(e)
+		if done {
+			s.links[0].detach()
+		}
+	})
+	return s
+}
@@ -0,0 +1,201 @@
+// Package stream declares pipelines of operators over event publishers.
+//
+// Each operator returns a Stream, a publisher of the derived events. A stream subscribes to its upstream
+// publishers and starts them when it is started, and only unsubscribes from them when it is stopped: the
+// dispatcher of a publisher is shared by its group. An upstream stream is stopped with its last downstream
+// stream, unless it was started on its own.
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/event"
+)
+
+// link the subscription of a stream to an upstream publisher
+type link struct {
+	start  func(ctx context.Context) error
+	stop   func(ctx context.Context) error
+	attach func()
+	detach func()
+}
+
+// shared an upstream stream, started and stopped by reference counting
+type shared interface {
+	retain(ctx context.Context) error
+	release(ctx context.Context) error
+}
+
+type handlerFunc[T event.Event] func(event T)
+
+func (hf *handlerFunc[T]) Handler(event T) {
+	(*hf)(event)
+}
+
+// Stream a publisher of the events derived by an operator from its upstream publishers
+type Stream[T event.Event] struct {
+	handlers map[interface{}]event.EventHandler[T]
+	links    []link
+	started  int32
+	// onStart and onStop manage the resources of time based operators
+	onStart func()
+	onStop  func()
+	mu      sync.RWMutex
+	// refs counts the started downstream streams, owned is true if the first of them started the stream
+	refs  int
+	owned bool
+	refMu sync.Mutex
+}
+
+var _ event.Publisher[event.AbstractEvent] = &Stream[event.AbstractEvent]{}
+
+func newStream[T event.Event]() *Stream[T] {
+	return &Stream[T]{handlers: make(map[interface{}]event.EventHandler[T])}
+}
+
+// connect subscribes the consumer to the upstream publisher while the stream is started
+func connect[T, R event.Event](s *Stream[R], upstream event.Publisher[T], consumer func(event T)) {
+	handler := handlerFunc[T](consumer)
+	l := link{
+		start:  upstream.Start,
+		attach: func() { upstream.AddHandler(&handler) },
+		detach: func() { upstream.RemoveHandler(&handler) },
+	}
+	if up, ok := upstream.(shared); ok {
+		l.start, l.stop = up.retain, up.release
+	}
+	s.links = append(s.links, l)
+}
+
+// Start subscribes to the upstream publishers and starts them
+func (s *Stream[T]) Start(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&s.started, 0, 1) {
+		return nil
+	}
+	for _, l := range s.links {
+		l.attach()
+	}
+	if s.onStart != nil {
+		s.onStart()
+	}
+	for _, l := range s.links {
+		if err := l.start(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stop unsubscribes from the upstream publishers, and stops the upstream streams it was the last to use
+func (s *Stream[T]) Stop(ctx context.Context) error {
+	if !atomic.CompareAndSwapInt32(&s.started, 1, 0) {
+		return nil
+	}
+	for _, l := range s.links {
+		l.detach()
+	}
+	if s.onStop != nil {
+		s.onStop()
+	}
+	var result error
+	for _, l := range s.links {
+		if l.stop == nil {
+			continue
+		}
+		if err := l.stop(ctx); err != nil && result == nil {
+			result = err
+		}
+	}
+	return result
+}
+
+// retain starts the stream for a downstream stream if it is not started
+func (s *Stream[T]) retain(ctx context.Context) error {
+	s.refMu.Lock()
+	s.refs++
+	start := s.refs == 1 && !s.IsStarted()
+	if start {
+		s.owned = true
+	}
+	s.refMu.Unlock()
+	if !start {
+		return nil
+	}
+	return s.Start(ctx)
+}
+
+// release stops the stream once no downstream stream uses it, if a downstream stream started it
+func (s *Stream[T]) release(ctx context.Context) error {
+	s.refMu.Lock()
+	if s.refs > 0 {
+		s.refs--
+	}
+	stop := s.refs == 0 && s.owned
+	if stop {
+		s.owned = false
+	}
+	s.refMu.Unlock()
+	if !stop {
+		return nil
+	}
+	return s.Stop(ctx)
+}
+
+// IsStarted reports whether the stream is subscribed to its upstream publishers
+func (s *Stream[T]) IsStarted() bool {
+	return atomic.LoadInt32(&s.started) == 1
+}
+
+func (s *Stream[T]) AddHandler(handler event.EventHandler[T]) bool {
+	if handler == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[handler] = handler
+	return true
+}
+
+func (s *Stream[T]) RemoveHandler(handler event.EventHandler[T]) bool {
+	if handler == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.handlers, handler)
+	return true
+}
+
+func (s *Stream[T]) Size() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.handlers)
+}
+
+// Offer delivers the event to the handlers of the stream in the calling goroutine
+func (s *Stream[T]) Offer(event T) bool {
+	if !s.IsStarted() {
+		return false
+	}
+	s.emit(event)
+	return true
+}
+
+func (s *Stream[T]) OfferWithTimeout(event T, duration time.Duration) bool {
+	return s.Offer(event)
+}
+
+func (s *Stream[T]) emit(e T) {
+	s.mu.RLock()
+	handlers := make([]event.EventHandler[T], 0, len(s.handlers))
+	for _, handler := range s.handlers {
+		handlers = append(handlers, handler)
+	}
+	s.mu.RUnlock()
+	for _, handler := range handlers {
+		handler.Handler(e)
+	}
+}
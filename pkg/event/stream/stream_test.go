@@ -0,0 +1,148 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+	"github.com/meshware/suit-kit-golang/pkg/event"
+	"github.com/meshware/suit-kit-golang/pkg/event/eventtest"
+)
+
+type TestEvent struct {
+	event.AbstractEvent
+}
+
+func newEvent(source interface{}) TestEvent {
+	return TestEvent{event.AbstractEvent{Source: source}}
+}
+
+func TestPipeline(t *testing.T) {
+	bus := eventtest.NewSyncBus[TestEvent]()
+	orders := bus.GetPublisher("order", "created")
+	refunds := bus.GetPublisher("order", "refunded")
+
+	// operators take publishers, so the type arguments of chained operators are explicit
+	merged := Merge(orders, refunds)
+	mapped := Map[TestEvent, TestEvent](merged, func(e TestEvent) TestEvent {
+		return newEvent(e.GetSource().(int) * 10)
+	})
+	filtered := Filter[TestEvent](mapped, func(e TestEvent) bool {
+		return e.GetSource().(int) > 10
+	})
+	distinct := Distinct[TestEvent, int](filtered, func(e TestEvent) int {
+		return e.GetSource().(int)
+	})
+	pipeline := Take[TestEvent](distinct, 3)
+	recorder := eventtest.NewRecordingHandler[TestEvent]()
+	pipeline.AddHandler(recorder)
+
+	orders.Offer(newEvent(5))
+	if actualValue := recorder.Len(); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+	if err := pipeline.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, source := range []int{1, 2, 3, 2, 4, 5} {
+		orders.Offer(newEvent(source))
+		refunds.Offer(newEvent(source + 100))
+	}
+	got := fmt.Sprint(recorder.Events())
+	expected := fmt.Sprint([]TestEvent{newEvent(1010), newEvent(20), newEvent(1020)})
+	if got != expected {
+		t.Errorf("Got %v expected %v", got, expected)
+	}
+	if actualValue := distinct.Size(); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+	_ = pipeline.Stop(context.Background())
+}
+
+func TestZip(t *testing.T) {
+	bus := eventtest.NewSyncBus[TestEvent]()
+	left, right := bus.GetPublisher("zip", "left"), bus.GetPublisher("zip", "right")
+	zipped := Zip(left, right, func(a, b TestEvent) TestEvent {
+		return newEvent(fmt.Sprint(a.GetSource(), b.GetSource()))
+	})
+	recorder := eventtest.NewRecordingHandler[TestEvent]()
+	zipped.AddHandler(recorder)
+	_ = zipped.Start(context.Background())
+
+	left.Offer(newEvent("a"))
+	left.Offer(newEvent("b"))
+	right.Offer(newEvent(1))
+	right.Offer(newEvent(2))
+	right.Offer(newEvent(3))
+	recorder.AssertReceived(t, eventtest.MatchSource[TestEvent]("a1"))
+	recorder.AssertReceived(t, eventtest.MatchSource[TestEvent]("b2"))
+	if actualValue := recorder.Len(); actualValue != 2 {
+		t.Errorf("Got %v expected %v", actualValue, 2)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Time{})
+	bus := eventtest.NewSyncBus[TestEvent]()
+	upstream := bus.GetPublisher("window", "default")
+	windows := WindowWithClock(upstream, time.Second, fakeClock)
+	recorder := eventtest.NewRecordingHandler[Batch[TestEvent]]()
+	windows.AddHandler(recorder)
+	_ = windows.Start(context.Background())
+
+	upstream.Offer(newEvent(1))
+	upstream.Offer(newEvent(2))
+	fakeClock.BlockUntil(1)
+	fakeClock.Advance(time.Second)
+	batches := recorder.AwaitEvents(t, 1, time.Second)
+	if actualValue := len(batches[0].Events); actualValue != 2 {
+		t.Errorf("Got %v expected %v", actualValue, 2)
+	}
+
+	upstream.Offer(newEvent(3))
+	_ = windows.Stop(context.Background())
+	batches = recorder.AwaitEvents(t, 2, time.Second)
+	if actualValue := batches[1].Events[0].GetSource(); actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+	if actualValue := upstream.Size(); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+}
+
+func TestStopKeepsGroupDispatcher(t *testing.T) {
+	bus := event.NewGoEventBus[TestEvent]()
+	orders := bus.GetPublisher("order", "created")
+	refunds := bus.GetPublisher("order", "refunded")
+	subscriber := eventtest.NewRecordingHandler[TestEvent]()
+	refunds.AddHandler(subscriber)
+	_ = refunds.Start(context.Background())
+	defer refunds.Stop(context.Background())
+
+	merged := Merge(orders, refunds)
+	mapped := Map[TestEvent, TestEvent](merged, func(e TestEvent) TestEvent {
+		return e
+	})
+	recorder := eventtest.NewRecordingHandler[TestEvent]()
+	mapped.AddHandler(recorder)
+	_ = mapped.Start(context.Background())
+	orders.Offer(newEvent(1))
+	recorder.AwaitEvents(t, 1, time.Second)
+	_ = mapped.Stop(context.Background())
+	if merged.IsStarted() {
+		t.Errorf("Got %v expected %v", true, false)
+	}
+	group := bus.Group("order")
+	if !group.Dispatcher().IsStarted() || !group.Contains("created") || !group.Contains("refunded") {
+		t.Errorf("Got %v expected %v", group.Publishers(), "the started publishers")
+	}
+
+	// the other subscriber of the group still receives
+	refunds.Offer(newEvent(2))
+	subscriber.AwaitMatching(t, eventtest.MatchSource[TestEvent](2), 1, time.Second)
+	if actualValue := recorder.Len(); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
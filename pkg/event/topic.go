@@ -0,0 +1,179 @@
+package event
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const (
+	// TopicSeparator separates the levels of a topic
+	TopicSeparator = "."
+	// SingleLevelWildcard matches exactly one level of a topic
+	SingleLevelWildcard = "*"
+	// MultiLevelWildcard matches zero or more levels of a topic
+	MultiLevelWildcard = "#"
+)
+
+// ValidateTopicPattern checks the levels of the pattern are not empty and wildcards are whole levels
+func ValidateTopicPattern(pattern string) error {
+	if len(pattern) == 0 {
+		return fmt.Errorf("event: empty topic pattern")
+	}
+	for _, level := range strings.Split(pattern, TopicSeparator) {
+		if len(level) == 0 {
+			return fmt.Errorf("event: empty level in topic pattern %q", pattern)
+		}
+		if level != SingleLevelWildcard && level != MultiLevelWildcard &&
+			strings.ContainsAny(level, SingleLevelWildcard+MultiLevelWildcard) {
+			return fmt.Errorf("event: wildcard must be a whole level in topic pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+type topicNode[T Event] struct {
+	children map[string]*topicNode[T]
+	handlers []EventHandler[T]
+}
+
+func newTopicNode[T Event]() *topicNode[T] {
+	return &topicNode[T]{children: make(map[string]*topicNode[T])}
+}
+
+func (tn *topicNode[T]) match(levels []string, i int, result *handlerSet[T]) {
+	if wildcard := tn.children[MultiLevelWildcard]; wildcard != nil {
+		for j := i; j <= len(levels); j++ {
+			wildcard.match(levels, j, result)
+		}
+	}
+	if i == len(levels) {
+		result.add(tn.handlers...)
+		return
+	}
+	if child := tn.children[levels[i]]; child != nil {
+		child.match(levels, i+1, result)
+	}
+	if wildcard := tn.children[SingleLevelWildcard]; wildcard != nil {
+		wildcard.match(levels, i+1, result)
+	}
+}
+
+// handlerSet keeps the handlers in insertion order without duplicates
+type handlerSet[T Event] struct {
+	handlers []EventHandler[T]
+	seen     map[interface{}]bool
+}
+
+func (hs *handlerSet[T]) add(handlers ...EventHandler[T]) {
+	for _, handler := range handlers {
+		if !hs.seen[handler] {
+			hs.seen[handler] = true
+			hs.handlers = append(hs.handlers, handler)
+		}
+	}
+}
+
+// TopicTrie indexes the handlers subscribed to topic patterns, the matches of a topic are cached
+// until the subscriptions change
+type TopicTrie[T Event] struct {
+	root  *topicNode[T]
+	size  int
+	cache map[string][]EventHandler[T]
+	mu    sync.RWMutex
+}
+
+func NewTopicTrie[T Event]() *TopicTrie[T] {
+	return &TopicTrie[T]{
+		root:  newTopicNode[T](),
+		cache: make(map[string][]EventHandler[T]),
+	}
+}
+
+// Subscribe adds the handler to the pattern, e.g. "order.*" or "order.#"
+func (tt *TopicTrie[T]) Subscribe(pattern string, handler EventHandler[T]) error {
+	if handler == nil {
+		return fmt.Errorf("event: nil handler for topic pattern %q", pattern)
+	}
+	if err := ValidateTopicPattern(pattern); err != nil {
+		return err
+	}
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	node := tt.root
+	for _, level := range strings.Split(pattern, TopicSeparator) {
+		child, ok := node.children[level]
+		if !ok {
+			child = newTopicNode[T]()
+			node.children[level] = child
+		}
+		node = child
+	}
+	for _, h := range node.handlers {
+		if h == handler {
+			return nil
+		}
+	}
+	node.handlers = append(node.handlers, handler)
+	tt.size++
+	tt.cache = make(map[string][]EventHandler[T])
+	return nil
+}
+
+// Unsubscribe removes the handler from the pattern
+func (tt *TopicTrie[T]) Unsubscribe(pattern string, handler EventHandler[T]) bool {
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	levels := strings.Split(pattern, TopicSeparator)
+	path := make([]*topicNode[T], 0, len(levels)+1)
+	node := tt.root
+	path = append(path, node)
+	for _, level := range levels {
+		if node = node.children[level]; node == nil {
+			return false
+		}
+		path = append(path, node)
+	}
+	for i, h := range node.handlers {
+		if h == handler {
+			node.handlers = append(node.handlers[:i], node.handlers[i+1:]...)
+			tt.size--
+			tt.cache = make(map[string][]EventHandler[T])
+			// prune the branches left without handlers
+			for j := len(levels); j > 0; j-- {
+				if n := path[j]; len(n.handlers) == 0 && len(n.children) == 0 {
+					delete(path[j-1].children, levels[j-1])
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the handlers subscribed to the patterns matching the topic
+func (tt *TopicTrie[T]) Match(topic string) []EventHandler[T] {
+	tt.mu.RLock()
+	handlers, ok := tt.cache[topic]
+	size := tt.size
+	tt.mu.RUnlock()
+	if ok || size == 0 {
+		return handlers
+	}
+	tt.mu.Lock()
+	defer tt.mu.Unlock()
+	if handlers, ok = tt.cache[topic]; !ok {
+		result := &handlerSet[T]{seen: make(map[interface{}]bool)}
+		tt.root.match(strings.Split(topic, TopicSeparator), 0, result)
+		handlers = result.handlers
+		tt.cache[topic] = handlers
+	}
+	return handlers
+}
+
+// Size returns the number of subscriptions
+func (tt *TopicTrie[T]) Size() int {
+	tt.mu.RLock()
+	defer tt.mu.RUnlock()
+	return tt.size
+}
@@ -0,0 +1,75 @@
+package event
+
+import (
+	"testing"
+)
+
+type countingHandler struct {
+	count int
+}
+
+func (ch *countingHandler) Handler(event TestEvent) {
+	ch.count++
+}
+
+func TestTopicTrieMatch(t *testing.T) {
+	trie := NewTopicTrie[TestEvent]()
+	single, multi, exact, tail := &countingHandler{}, &countingHandler{}, &countingHandler{}, &countingHandler{}
+	_ = trie.Subscribe("order.*", single)
+	_ = trie.Subscribe("order.#", multi)
+	_ = trie.Subscribe("order.created", exact)
+	_ = trie.Subscribe("#.paid", tail)
+
+	tests := []struct {
+		topic string
+		want  []EventHandler[TestEvent]
+	}{
+		{"order", []EventHandler[TestEvent]{multi}},
+		{"order.created", []EventHandler[TestEvent]{multi, single, exact}},
+		{"order.paid", []EventHandler[TestEvent]{multi, single, tail}},
+		{"order.item.paid", []EventHandler[TestEvent]{multi, tail}},
+		{"payment.paid", []EventHandler[TestEvent]{tail}},
+		{"payment.created", nil},
+	}
+	for _, tt := range tests {
+		got := trie.Match(tt.topic)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: Got %v expected %v", tt.topic, len(got), len(tt.want))
+			continue
+		}
+		for _, handler := range tt.want {
+			found := false
+			for _, h := range got {
+				found = found || h == handler
+			}
+			if !found {
+				t.Errorf("%s: missing handler %p", tt.topic, handler)
+			}
+		}
+	}
+
+	if actualValue := trie.Unsubscribe("order.#", multi); actualValue != true {
+		t.Errorf("Got %v expected %v", actualValue, true)
+	}
+	if actualValue := len(trie.Match("order.item.paid")); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+	if err := trie.Subscribe("order.cre*", single); err == nil {
+		t.Errorf("Got %v expected an error", err)
+	}
+}
+
+func TestBusSubscribe(t *testing.T) {
+	bus := NewGoEventBus[TestEvent]()
+	bus.DefaultConfig = &PublisherConfig{Sync: true}
+	subscriber := &countingHandler{}
+	if err := bus.Subscribe("order.*", subscriber); err != nil {
+		t.Fatal(err)
+	}
+	bus.GetPublisher("order", "created").Offer(TestEvent{AbstractEvent{Source: "o-1"}})
+	bus.GetPublisher("order", "paid").Offer(TestEvent{AbstractEvent{Source: "o-1", Target: subscriber}})
+	bus.GetPublisher("payment", "paid").Offer(TestEvent{AbstractEvent{Source: "p-1"}})
+	if actualValue := subscriber.count; actualValue != 2 {
+		t.Errorf("Got %v expected %v", actualValue, 2)
+	}
+}
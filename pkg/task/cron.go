@@ -0,0 +1,264 @@
+package task
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule the fire times of a cron expression
+type CronSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	location                              *time.Location
+}
+
+type cronField struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = cronField{name: "second", min: 0, max: 59}
+	minuteField = cronField{name: "minute", min: 0, max: 59}
+	hourField   = cronField{name: "hour", min: 0, max: 23}
+	domField    = cronField{name: "day of month", min: 1, max: 31}
+	monthField  = cronField{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// 7 is also sunday
+	dowField = cronField{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 0 1 1 *",
+	"@annually": "0 0 0 1 1 *",
+	"@monthly":  "0 0 0 1 * *",
+	"@weekly":   "0 0 0 * * 0",
+	"@daily":    "0 0 0 * * *",
+	"@midnight": "0 0 0 * * *",
+	"@hourly":   "0 0 * * * *",
+}
+
+// starBit marks a field given as * or ?, which matters to combine the days of month and of week
+const starBit = 1 << 63
+
+// ParseCron parses a cron expression in the local time zone. The expression has 5 fields
+// (minute hour day-of-month month day-of-week), an optional leading seconds field, or is one of the
+// macros @yearly, @annually, @monthly, @weekly, @daily, @midnight and @hourly.
+func ParseCron(spec string) (*CronSchedule, error) {
+	return ParseCronInLocation(spec, time.Local)
+}
+
+// ParseCronInLocation parses a cron expression evaluated in the location
+func ParseCronInLocation(spec string, location *time.Location) (*CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := cronMacros[strings.ToLower(spec)]
+		if !ok {
+			return nil, fmt.Errorf("cron: unknown macro %q", spec)
+		}
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, fmt.Errorf("cron: expected 5 or 6 fields, got %d in %q", len(fields), spec)
+	}
+	if location == nil {
+		location = time.Local
+	}
+	schedule := &CronSchedule{location: location}
+	targets := []*uint64{&schedule.second, &schedule.minute, &schedule.hour, &schedule.dom, &schedule.month, &schedule.dow}
+	for i, field := range []cronField{secondField, minuteField, hourField, domField, monthField, dowField} {
+		bits, err := field.parse(fields[i])
+		if err != nil {
+			return nil, err
+		}
+		*targets[i] = bits
+	}
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow |= 1
+	}
+	return schedule, nil
+}
+
+func (f cronField) parse(expr string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(expr, ",") {
+		rangePart, step := part, 1
+		if i := strings.IndexByte(part, '/'); i >= 0 {
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("cron: invalid step in %s %q", f.name, part)
+			}
+			rangePart = part[:i]
+		}
+		var low, high int
+		switch {
+		case rangePart == "*" || rangePart == "?":
+			low, high = f.min, f.max
+			if step == 1 {
+				bits |= starBit
+			}
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if high, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if low, err = f.value(rangePart); err != nil {
+				return 0, err
+			}
+			high = low
+			if step > 1 {
+				high = f.max
+			}
+		}
+		if low > high {
+			return 0, fmt.Errorf("cron: invalid range in %s %q", f.name, part)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+func (f cronField) value(s string) (int, error) {
+	if v, ok := f.names[strings.ToLower(s)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("cron: invalid %s %q", f.name, s)
+	}
+	return v, nil
+}
+
+func (cs *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := cs.dom&(1<<uint(t.Day())) != 0
+	dowMatch := cs.dow&(1<<uint(t.Weekday())) != 0
+	// when both days are restricted, either of them matches
+	if cs.dom&starBit != 0 || cs.dow&starBit != 0 {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first fire time after t, the zero time if there is none within five years
+func (cs *CronSchedule) Next(t time.Time) time.Time {
+	t = t.In(cs.location)
+	t = t.Add(time.Second - time.Duration(t.Nanosecond()))
+	yearLimit := t.Year() + 5
+	added := false
+wrap:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+	for cs.month&(1<<uint(t.Month())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, cs.location)
+		}
+		t = t.AddDate(0, 1, 0)
+		if t.Month() == time.January {
+			goto wrap
+		}
+	}
+	for !cs.dayMatches(t) {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, cs.location)
+		}
+		t = t.AddDate(0, 0, 1)
+		if t.Day() == 1 {
+			goto wrap
+		}
+	}
+	for cs.hour&(1<<uint(t.Hour())) == 0 {
+		if !added {
+			added = true
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, cs.location)
+		}
+		t = t.Add(time.Hour)
+		if t.Hour() == 0 {
+			goto wrap
+		}
+	}
+	for cs.minute&(1<<uint(t.Minute())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Minute)
+		}
+		t = t.Add(time.Minute)
+		if t.Minute() == 0 {
+			goto wrap
+		}
+	}
+	for cs.second&(1<<uint(t.Second())) == 0 {
+		if !added {
+			added = true
+			t = t.Truncate(time.Second)
+		}
+		t = t.Add(time.Second)
+		if t.Second() == 0 {
+			goto wrap
+		}
+	}
+	return t
+}
+
+// Cron runs the runnable at each fire time of the cron expression until the returned Timeout is
+// cancelled. Missed fire times are coalesced into one run unless the misfire policy says otherwise.
+func (ts *TimeScheduler) Cron(name, spec string, runnable func(), opts ...TaskOption) (Timeout, error) {
+	if runnable == nil {
+		return nil, fmt.Errorf("cron: nil runnable")
+	}
+	schedule, err := ParseCron(spec)
+	if err != nil {
+		return nil, err
+	}
+	return ts.CronSchedule(name, schedule, runnable, opts...)
+}
+
+// CronSchedule runs the runnable at each fire time of the schedule until the returned Timeout is cancelled
+func (ts *TimeScheduler) CronSchedule(name string, schedule *CronSchedule, runnable func(),
+	opts ...TaskOption) (Timeout, error) {
+	first := schedule.Next(ts.clock.Now())
+	if first.IsZero() {
+		return nil, fmt.Errorf("cron: %s never fires", name)
+	}
+	tw := newTimeWork(name, first.UnixMilli(), runnableFunc(runnable), ts.afterRun, ts.afterCancel)
+	tw.next = cronNext(schedule)
+	for _, opt := range opts {
+		opt(tw)
+	}
+	return ts.add(tw), nil
+}
+
+// cronNext returns the next time of the schedule after the last occurrence, missed times are skipped
+func cronNext(schedule *CronSchedule) func(last, now int64) int64 {
+	return func(last, now int64) int64 {
+		if now > last {
+			last = now
+		}
+		next := schedule.Next(time.UnixMilli(last))
+		if next.IsZero() {
+			return -1
+		}
+		return next.UnixMilli()
+	}
+}
@@ -0,0 +1,117 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+func TestParseCron(t *testing.T) {
+	base := time.Date(2024, time.January, 31, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		spec     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2024, time.January, 31, 10, 18, 0, 0, time.UTC)},
+		{"0 */5 * * * *", time.Date(2024, time.January, 31, 10, 20, 0, 0, time.UTC)},
+		{"*/10 * * * * *", time.Date(2024, time.January, 31, 10, 17, 40, 0, time.UTC)},
+		{"30 9 * * mon-fri", time.Date(2024, time.February, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 0 29 feb *", time.Date(2024, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"0 12 1,15 * *", time.Date(2024, time.February, 1, 12, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.February, 4, 0, 0, 0, 0, time.UTC)},
+		{"0 0 13 * 5", time.Date(2024, time.February, 2, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.January, 31, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		schedule, err := ParseCronInLocation(test.spec, time.UTC)
+		if err != nil {
+			t.Errorf("Got %v expected %v", err, nil)
+			continue
+		}
+		if actualValue := schedule.Next(base); !actualValue.Equal(test.expected) {
+			t.Errorf("%s: Got %v expected %v", test.spec, actualValue, test.expected)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "61 * * * * *", "* * * * * * *", "5-1 * * * *", "*/0 * * * *", "@never", "* * * jan-foo *"} {
+		if _, err := ParseCron(spec); err == nil {
+			t.Errorf("%q: Got %v expected an error", spec, err)
+		}
+	}
+	never, _ := ParseCronInLocation("0 0 30 2 *", time.UTC)
+	if actualValue := never.Next(base); !actualValue.IsZero() {
+		t.Errorf("Got %v expected %v", actualValue, time.Time{})
+	}
+}
+
+func TestCron(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Time{})
+	scheduler := NewTimeScheduler("cron", 10, 100, 2, WithClock(fakeClock))
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	runs := make(chan time.Time, 10)
+	timeout, err := scheduler.Cron("every-second", "* * * * * *", func() {
+		runs <- fakeClock.Now()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i <= 2; i++ {
+		fakeClock.Advance(time.Second)
+		select {
+		case run := <-runs:
+			if actualValue := run.Second(); actualValue != i {
+				t.Errorf("Got %v expected %v", actualValue, i)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("the cron task did not run")
+		}
+	}
+	if !timeout.Cancel() {
+		t.Errorf("Got %v expected %v", false, true)
+	}
+	if timeout.IsExpired() {
+		t.Errorf("Got %v expected %v", true, false)
+	}
+	fakeClock.Advance(time.Second)
+	time.Sleep(20 * time.Millisecond)
+	if actualValue := len(runs); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+	if _, err = scheduler.Cron("invalid", "* * *", func() {}); err == nil {
+		t.Errorf("Got %v expected an error", err)
+	}
+}
+
+func TestCronRetry(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("cron", 10, 100, 2, WithClock(fakeClock))
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	// the failed run of the first second is retried after 100ms
+	runs := make(chan int64, 10)
+	_, err := scheduler.Cron("every-second", "* * * * * *", func() {
+		runs <- fakeClock.Now().UnixMilli()
+		if len(runs) == 1 {
+			panic("boom")
+		}
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, InitialDelay: 100}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	awaitNext(t, scheduler, "every-second", 1000)
+	fakeClock.Set(time.UnixMilli(1000))
+	awaitNext(t, scheduler, "every-second", 1100)
+	fakeClock.Set(time.UnixMilli(1100))
+	awaitNext(t, scheduler, "every-second", 2000)
+	if actualValue := fmt.Sprint(<-runs, <-runs); actualValue != "1000 1100" {
+		t.Errorf("Got %v expected %v", actualValue, "1000 1100")
+	}
+}
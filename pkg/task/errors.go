@@ -0,0 +1,50 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+// PanicError a panic recovered from a task
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (pe *PanicError) Error() string {
+	return fmt.Sprintf("task: panic: %v", pe.Value)
+}
+
+// ErrorHook receives the name of the task and its error, the stack is set for a panic
+type ErrorHook func(name string, err error, stack []byte)
+
+func (ts *TimeScheduler) report(name string, err error) {
+	var stack []byte
+	var panicError *PanicError
+	if errors.As(err, &panicError) {
+		stack = panicError.Stack
+		ts.error("Task panicked", log.String("task", name), log.Any("panic", panicError.Value),
+			log.ByteString("stack", stack))
+		ts.hook(ts.OnPanic, name, err, stack)
+	} else {
+		ts.warn("Task failed", log.String("task", name), log.String("error", err.Error()))
+	}
+	ts.hook(ts.OnError, name, err, stack)
+}
+
+// hook calls the hook, a panic of the hook is logged so that the worker survives it
+func (ts *TimeScheduler) hook(hook ErrorHook, name string, err error, stack []byte) {
+	if hook == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ts.error("Error hook panicked", log.String("task", name), log.Any("panic", r),
+				log.ByteString("stack", debug.Stack()))
+		}
+	}()
+	hook(name, err, stack)
+}
@@ -0,0 +1,99 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	scheduler := NewTimeScheduler("panic", 10, 100, 2)
+	var panics, failures []string
+	var mu sync.Mutex
+	scheduler.OnPanic = func(name string, err error, stack []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !strings.Contains(string(stack), "TestPanicRecovery") {
+			t.Errorf("Got %s expected the stack of the task", stack)
+		}
+		panics = append(panics, name)
+	}
+	scheduler.OnError = func(name string, err error, stack []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		failures = append(failures, name)
+	}
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	for i := 0; i < 5; i++ {
+		scheduler.Delay(fmt.Sprint("panic-", i), 0, func() {
+			panic("boom")
+		})
+	}
+	failure := errors.New("failure")
+	failed := scheduler.DelayFunc("failed", 0, func(ctx context.Context) error {
+		return failure
+	})
+	// the workers survive the panics
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	panicked := scheduler.DelayFunc("panicked", 10, func(ctx context.Context) error {
+		panic("boom")
+	})
+	var panicError *PanicError
+	if err := panicked.Wait(ctx); !errors.As(err, &panicError) || panicError.Value != "boom" {
+		t.Errorf("Got %v expected %v", err, "a panic error")
+	}
+	if err := failed.Wait(ctx); err != failure {
+		t.Errorf("Got %v expected %v", err, failure)
+	}
+	ok := scheduler.DelayFunc("ok", 10, func(ctx context.Context) error {
+		return nil
+	})
+	if err := ok.Wait(ctx); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if actualValue := len(panics); actualValue != 6 {
+		t.Errorf("Got %v expected %v", actualValue, 6)
+	}
+	if actualValue := len(failures); actualValue != 7 {
+		t.Errorf("Got %v expected %v", actualValue, 7)
+	}
+}
+
+func TestPanickingHook(t *testing.T) {
+	scheduler := NewTimeScheduler("hook", 10, 100, 1)
+	scheduler.OnPanic = func(name string, err error, stack []byte) {
+		panic("hook")
+	}
+	scheduler.OnError = func(name string, err error, stack []byte) {
+		panic("hook")
+	}
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	panicked := scheduler.DelayFunc("panicked", 0, func(ctx context.Context) error {
+		panic("boom")
+	})
+	var panicError *PanicError
+	if err := panicked.Wait(ctx); !errors.As(err, &panicError) {
+		t.Errorf("Got %v expected %v", err, "a panic error")
+	}
+	// the single worker survives the hooks
+	ok := scheduler.DelayFunc("ok", 0, func(ctx context.Context) error {
+		return nil
+	})
+	if err := ok.Wait(ctx); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+}
@@ -0,0 +1,78 @@
+package task
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	// ErrNotDone the result of a task that did not complete yet
+	ErrNotDone = errors.New("task: not done")
+	// ErrCancelled the result of a cancelled task
+	ErrCancelled = errors.New("task: cancelled")
+)
+
+// Future a Timeout giving access to the outcome of the task. A recurring task is done once it is
+// cancelled or its last occurrence ran.
+type Future interface {
+	Timeout
+	// Wait blocks until the task is done and returns its result, or the error of the context
+	Wait(ctx context.Context) error
+	// Done is closed once the task is done
+	Done() <-chan struct{}
+	// Result returns the error of the task, ErrCancelled if it was cancelled, ErrNotDone if it is not done
+	Result() error
+}
+
+var _ Future = &TimeWork{}
+
+// AddFunc runs the function at the time in milliseconds, the context is cancelled when the scheduler
+// is closed. The addition is never refused like Add.
+func (ts *TimeScheduler) AddFunc(name string, time int64, fn func(ctx context.Context) error,
+	opts ...TaskOption) Future {
+	if fn == nil {
+		return nil
+	}
+	tw := newTimeWork(name, time, fn, ts.afterRun, ts.afterCancel)
+	for _, opt := range opts {
+		opt(tw)
+	}
+	return ts.add(tw)
+}
+
+// DelayFunc runs the function after the delay in milliseconds, the context is cancelled when the
+// scheduler is closed
+func (ts *TimeScheduler) DelayFunc(name string, delay int64, fn func(ctx context.Context) error,
+	opts ...TaskOption) Future {
+	return ts.AddFunc(name, ts.now()+delay, fn, opts...)
+}
+
+func (tw *TimeWork) Wait(ctx context.Context) error {
+	select {
+	case <-tw.done:
+		return tw.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (tw *TimeWork) Done() <-chan struct{} {
+	return tw.done
+}
+
+func (tw *TimeWork) Result() error {
+	select {
+	case <-tw.done:
+		return tw.err
+	default:
+		return ErrNotDone
+	}
+}
+
+// complete records the result, only the first one is kept
+func (tw *TimeWork) complete(err error) {
+	tw.doneOnce.Do(func() {
+		tw.err = err
+		close(tw.done)
+	})
+}
@@ -0,0 +1,60 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFuture(t *testing.T) {
+	scheduler := NewTimeScheduler("future", 10, 100, 2)
+	scheduler.Start(context.Background())
+
+	failure := errors.New("failure")
+	failed := scheduler.DelayFunc("failed", 20, func(ctx context.Context) error {
+		return failure
+	})
+	if actualValue := failed.Result(); actualValue != ErrNotDone {
+		t.Errorf("Got %v expected %v", actualValue, ErrNotDone)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if actualValue := failed.Wait(ctx); actualValue != failure {
+		t.Errorf("Got %v expected %v", actualValue, failure)
+	}
+	if !failed.IsExpired() {
+		t.Errorf("Got %v expected %v", false, true)
+	}
+
+	cancelled := scheduler.DelayFunc("cancelled", 1000, func(ctx context.Context) error {
+		return nil
+	})
+	cancelled.Cancel()
+	<-cancelled.Done()
+	if actualValue := cancelled.Result(); actualValue != ErrCancelled {
+		t.Errorf("Got %v expected %v", actualValue, ErrCancelled)
+	}
+
+	short, cancelShort := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelShort()
+	pending := scheduler.DelayFunc("pending", 1000, func(ctx context.Context) error {
+		return nil
+	})
+	if actualValue := pending.Wait(short); actualValue != context.DeadlineExceeded {
+		t.Errorf("Got %v expected %v", actualValue, context.DeadlineExceeded)
+	}
+
+	// the running task sees the cancellation of the scheduler
+	started := make(chan struct{})
+	running := scheduler.DelayFunc("running", 0, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	scheduler.Close()
+	if actualValue := running.Wait(ctx); actualValue != context.Canceled {
+		t.Errorf("Got %v expected %v", actualValue, context.Canceled)
+	}
+}
@@ -0,0 +1,143 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+// DefaultQueueCapacity the default capacity of the additions waiting for the queue, and of the due runs
+// waiting for a worker
+const DefaultQueueCapacity = 1000
+
+// ErrQueueFull the error of TryAdd when the additions waiting for the queue reached its capacity
+var ErrQueueFull = errors.New("task: queue full")
+
+// WithQueueCapacity bounds the additions waiting for the queue, TryAdd fails beyond it while the other
+// additions are never refused. A capacity which is not positive means DefaultQueueCapacity.
+func WithQueueCapacity(capacity int) SchedulerOption {
+	return func(ts *TimeScheduler) {
+		if capacity <= 0 {
+			capacity = DefaultQueueCapacity
+		}
+		ts.queueCapacity = capacity
+	}
+}
+
+// WithRunQueueCapacity sets the capacity of the due runs waiting for a worker, the queue waits for the
+// workers beyond it. A negative capacity means DefaultQueueCapacity.
+func WithRunQueueCapacity(capacity int) SchedulerOption {
+	return func(ts *TimeScheduler) {
+		if capacity < 0 {
+			capacity = DefaultQueueCapacity
+		}
+		ts.runQueueCapacity = capacity
+	}
+}
+
+// inbox holds the requests to the queue, the senders never block and the queue takes them all at each pass.
+// additions counts the arms added by the users, the occurrences armed again by the scheduler are not bounded.
+type inbox struct {
+	arms      []occurrence
+	moves     []occurrence
+	cancels   []*TimeWork
+	additions int
+	mu        sync.Mutex
+}
+
+// TryAdd runs the function at the time in milliseconds, unless the scheduler is saturated. The error is
+// ErrQueueFull, ErrStopped or ErrDuplicate when the task is refused.
+func (ts *TimeScheduler) TryAdd(name string, time int64, fn func(ctx context.Context) error,
+	opts ...TaskOption) (Future, error) {
+	if fn == nil {
+		return nil, fmt.Errorf("task: no function for %s", name)
+	}
+	tw := newTimeWork(name, time, fn, ts.afterRun, ts.afterCancel)
+	for _, opt := range opts {
+		opt(tw)
+	}
+	if err := ts.submit(tw, true); err != nil {
+		return tw, err
+	}
+	return tw, nil
+}
+
+// postAddition queues the placement of an added task, it returns false if the bounded addition finds the
+// additions at the capacity
+func (ts *TimeScheduler) postAddition(o occurrence, bounded bool) bool {
+	ts.inbox.mu.Lock()
+	if bounded && ts.inbox.additions >= ts.queueCapacity {
+		ts.inbox.mu.Unlock()
+		return false
+	}
+	ts.inbox.additions++
+	ts.inbox.arms = append(ts.inbox.arms, o)
+	ts.inbox.mu.Unlock()
+	ts.nudge()
+	return true
+}
+
+// post queues the placement of a task armed again by the scheduler
+func (ts *TimeScheduler) post(o occurrence) {
+	ts.inbox.mu.Lock()
+	ts.inbox.arms = append(ts.inbox.arms, o)
+	ts.inbox.mu.Unlock()
+	ts.nudge()
+}
+
+func (ts *TimeScheduler) postMove(o occurrence) {
+	ts.inbox.mu.Lock()
+	ts.inbox.moves = append(ts.inbox.moves, o)
+	ts.inbox.mu.Unlock()
+	ts.nudge()
+}
+
+func (ts *TimeScheduler) postCancel(tw *TimeWork) {
+	ts.inbox.mu.Lock()
+	ts.inbox.cancels = append(ts.inbox.cancels, tw)
+	ts.inbox.mu.Unlock()
+	ts.nudge()
+}
+
+// take empties the inbox
+func (ts *TimeScheduler) take() (arms, moves []occurrence, cancels []*TimeWork) {
+	ts.inbox.mu.Lock()
+	defer ts.inbox.mu.Unlock()
+	arms, moves, cancels = ts.inbox.arms, ts.inbox.moves, ts.inbox.cancels
+	ts.inbox.arms, ts.inbox.moves, ts.inbox.cancels = nil, nil, nil
+	ts.inbox.additions = 0
+	return arms, moves, cancels
+}
+
+// receive processes the requests of the inbox, the cancellations first
+func (ts *TimeScheduler) receive(currentTime int64) {
+	arms, moves, cancels := ts.take()
+	for _, tw := range cancels {
+		tw.remove()
+	}
+	for _, o := range arms {
+		ts.arm(o, currentTime)
+	}
+	for _, o := range moves {
+		ts.move(o, currentTime)
+	}
+}
+
+// backlog returns the number of additions waiting for the queue
+func (ts *TimeScheduler) backlog() int {
+	ts.inbox.mu.Lock()
+	defer ts.inbox.mu.Unlock()
+	return ts.inbox.additions
+}
+
+// refuse completes the task which was not queued with the error
+func (ts *TimeScheduler) refuse(tw *TimeWork, err error) error {
+	ts.debug("Task refused", log.String("task", tw.name), log.String("error", err.Error()))
+	atomic.StoreInt32(&tw.state, CANCELLED)
+	tw.complete(err)
+	return err
+}
@@ -0,0 +1,108 @@
+package task
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTryAdd(t *testing.T) {
+	scheduler := NewTimeScheduler("try", 10, 100, 2, WithQueueCapacity(2))
+	noop := func(ctx context.Context) error {
+		return nil
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := scheduler.TryAdd("accepted", 0, noop); err != nil {
+			t.Errorf("Got %v expected %v", err, nil)
+		}
+	}
+	refused, err := scheduler.TryAdd("refused", 0, noop)
+	if err != ErrQueueFull {
+		t.Errorf("Got %v expected %v", err, ErrQueueFull)
+	}
+	if actualValue := refused.Result(); actualValue != ErrQueueFull {
+		t.Errorf("Got %v expected %v", actualValue, ErrQueueFull)
+	}
+	if _, ok := scheduler.Get("refused"); ok {
+		t.Errorf("Got %v expected %v", ok, false)
+	}
+	// the other additions are not bounded
+	if actualValue := scheduler.DelayFunc("unbounded", 0, noop).Result(); actualValue != ErrNotDone {
+		t.Errorf("Got %v expected %v", actualValue, ErrNotDone)
+	}
+	if actualValue := scheduler.Stats().Pending; actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+	if actualValue := scheduler.Stats().Backlog; actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+	time.Sleep(50 * time.Millisecond)
+	if _, err = scheduler.TryAdd("accepted", 0, noop); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+}
+
+func TestQueueCapacity(t *testing.T) {
+	scheduler := NewTimeScheduler("capacity", 10, 100, 2, WithQueueCapacity(0), WithRunQueueCapacity(-1))
+	defer scheduler.Close()
+	if actualValue := scheduler.queueCapacity; actualValue != DefaultQueueCapacity {
+		t.Errorf("Got %v expected %v", actualValue, DefaultQueueCapacity)
+	}
+	if actualValue := cap(scheduler.working); actualValue != DefaultQueueCapacity {
+		t.Errorf("Got %v expected %v", actualValue, DefaultQueueCapacity)
+	}
+
+	// the occurrences armed again by the scheduler do not count against the capacity
+	bounded := NewTimeScheduler("bounded", 10, 100, 2, WithQueueCapacity(1))
+	defer bounded.Close()
+	noop := func(ctx context.Context) error {
+		return nil
+	}
+	bounded.DelayFunc("requeued", 1000, noop)
+	arms, _, _ := bounded.take()
+	for i := 0; i < 3; i++ {
+		bounded.post(arms[0])
+	}
+	if _, err := bounded.TryAdd("accepted", 0, noop); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+	if _, err := bounded.TryAdd("refused", 0, noop); err != ErrQueueFull {
+		t.Errorf("Got %v expected %v", err, ErrQueueFull)
+	}
+	if actualValue := bounded.Stats().Backlog; actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
+
+func TestAddNeverBlocks(t *testing.T) {
+	scheduler := NewTimeScheduler("burst", 10, 100, 4)
+	var wg sync.WaitGroup
+	wg.Add(5000)
+	// far more additions and cancellations than the capacity, before Start
+	for i := 0; i < 5000; i++ {
+		scheduler.Delay("burst", 0, wg.Done)
+	}
+	for i := 0; i < 5000; i++ {
+		scheduler.Delay("cancelled", 1000, func() {}).Cancel()
+	}
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the tasks did not run")
+	}
+	if actualValue := scheduler.Stats().Cancelled; actualValue != 5000 {
+		t.Errorf("Got %v expected %v", actualValue, 5000)
+	}
+}
@@ -0,0 +1,166 @@
+package task
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+// JobHandler runs the jobs registered with its name
+type JobHandler func(ctx context.Context, payload []byte) error
+
+// RegisterHandler registers the handler of the jobs, it must be registered before the jobs are loaded by
+// Start
+func (ts *TimeScheduler) RegisterHandler(name string, handler JobHandler) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if handler == nil {
+		delete(ts.handlers, name)
+		return
+	}
+	ts.handlers[name] = handler
+}
+
+func (ts *TimeScheduler) handler(name string) JobHandler {
+	ts.mu.RLock()
+	defer ts.mu.RUnlock()
+	return ts.handlers[name]
+}
+
+// ScheduleJob schedules the job and persists it in the job store until it runs or is cancelled. A cron
+// job without time starts at the next time of its expression. An overdue job is processed according to its
+// misfire policy, the returned Future completes with ErrMisfired if the job is dropped. The store keeps one
+// job by name, so a job replaces the pending task with its name unless the scheduler refuses duplicates.
+func (ts *TimeScheduler) ScheduleJob(job Job) (Future, error) {
+	if len(job.Name) == 0 {
+		return nil, fmt.Errorf("task: job without name")
+	}
+	if ts.handler(job.Handler) == nil {
+		return nil, fmt.Errorf("task: no handler %q for job %s", job.Handler, job.Name)
+	}
+	if len(job.Misfire) == 0 {
+		job.Misfire = MisfireFireOnce
+	}
+	schedule, err := job.schedule()
+	if err != nil {
+		return nil, err
+	}
+	if schedule != nil && job.Time == 0 {
+		next := schedule.Next(ts.clock.Now())
+		if next.IsZero() {
+			return nil, fmt.Errorf("task: job %s never fires", job.Name)
+		}
+		job.Time = next.UnixMilli()
+	}
+	// the job is saved once its name is registered, a refused job leaves the store untouched
+	tw := ts.newJobWork(job, schedule)
+	tw.persist = func() error {
+		return ts.saveJob(job)
+	}
+	if err = ts.submit(tw, false); err != nil {
+		return nil, err
+	}
+	return tw, nil
+}
+
+func (job Job) schedule() (*CronSchedule, error) {
+	if len(job.Cron) == 0 {
+		return nil, nil
+	}
+	return ParseCron(job.Cron)
+}
+
+// loadJobs reads the persisted jobs, they are submitted when the scheduler starts
+func (ts *TimeScheduler) loadJobs() error {
+	if ts.store == nil {
+		return nil
+	}
+	jobs, err := ts.store.Load()
+	if err != nil {
+		err = fmt.Errorf("task: load jobs: %w", err)
+		ts.report(ts.prefix, err)
+		return err
+	}
+	ts.loaded = jobs
+	return nil
+}
+
+// submitJobs schedules the loaded jobs, the jobs whose handler is not registered stay in the store
+func (ts *TimeScheduler) submitJobs() {
+	jobs := ts.loaded
+	ts.loaded = nil
+	for _, job := range jobs {
+		if ts.handler(job.Handler) == nil {
+			ts.warn("Job without handler", log.String("task", job.Name), log.String("handler", job.Handler))
+			continue
+		}
+		schedule, err := job.schedule()
+		if err != nil {
+			ts.report(job.Name, err)
+			continue
+		}
+		ts.add(ts.newJobWork(job, schedule))
+	}
+}
+
+// newJobWork creates the task of the job, an overdue job is processed according to its misfire policy
+func (ts *TimeScheduler) newJobWork(job Job, schedule *CronSchedule) *TimeWork {
+	var tw *TimeWork
+	runnable := func(ctx context.Context) error {
+		handler := ts.handler(job.Handler)
+		if handler == nil {
+			return fmt.Errorf("task: no handler %q for job %s", job.Handler, job.Name)
+		}
+		err := handler(ctx, job.Payload)
+		// the next occurrence of a cron job is armed before its run
+		if schedule != nil && !tw.IsExpired() {
+			persisted := job
+			persisted.Time = tw.at()
+			if saveErr := ts.saveJob(persisted); saveErr != nil {
+				ts.report(job.Name, saveErr)
+			}
+		}
+		return err
+	}
+	// a replacing job keeps the record of the name
+	tw = newTimeWork(job.Name, job.Time, runnable, func(tw *TimeWork) {
+		ts.afterRun(tw)
+		if !ts.replaced(tw) {
+			ts.deleteJob(tw.name)
+		}
+	}, func(tw *TimeWork) {
+		ts.afterCancel(tw)
+		if !ts.replaced(tw) {
+			ts.deleteJob(tw.name)
+		}
+	})
+	if schedule != nil {
+		tw.next = cronNext(schedule)
+	}
+	tw.misfire = job.Misfire
+	tw.threshold = job.MisfireThreshold
+	if job.Retry != nil {
+		WithRetry(*job.Retry)(tw)
+	}
+	if ts.duplicates != DuplicateRefuse {
+		tw.duplicates = DuplicateReplace
+	}
+	return tw
+}
+
+func (ts *TimeScheduler) saveJob(job Job) error {
+	if ts.store == nil {
+		return nil
+	}
+	return ts.store.Save(job)
+}
+
+func (ts *TimeScheduler) deleteJob(name string) {
+	if ts.store == nil {
+		return
+	}
+	if err := ts.store.Delete(name); err != nil {
+		ts.report(name, err)
+	}
+}
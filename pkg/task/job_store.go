@@ -0,0 +1,233 @@
+package task
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/meshware/suit-kit-golang/pkg/encoding/json"
+)
+
+// Job a persistent task, run by the handler registered with its name
+type Job struct {
+	// Name identifies the job in the store, it is the name of its task
+	Name    string `json:"name"`
+	Handler string `json:"handler"`
+	Payload []byte `json:"payload,omitempty"`
+	// Time of the next run in milliseconds
+	Time int64 `json:"time"`
+	// Cron makes the job recurring, Time is then updated after each run
+	Cron    string        `json:"cron,omitempty"`
+	Misfire MisfirePolicy `json:"misfire,omitempty"`
+	// MisfireThreshold the lateness in milliseconds beyond which the skip and drop policies apply
+	MisfireThreshold int64 `json:"misfireThreshold,omitempty"`
+	// Retry retries the failed runs of the job
+	Retry *RetryPolicy `json:"retry,omitempty"`
+}
+
+// JobStore persists the jobs of a scheduler
+type JobStore interface {
+	Save(job Job) error
+	Delete(name string) error
+	// Load returns the jobs ordered by time
+	Load() ([]Job, error)
+}
+
+// fileJobRecord a line of the job file
+type fileJobRecord struct {
+	Op   string `json:"op"`
+	Name string `json:"name,omitempty"`
+	Job  *Job   `json:"job,omitempty"`
+}
+
+const (
+	jobSaveOp   = "save"
+	jobDeleteOp = "delete"
+	// compactThreshold the number of obsolete records that triggers a compaction
+	compactThreshold = 64
+)
+
+// FileJobStore a JobStore appending the changes to a JSON lines file, the file is compacted once the
+// obsolete records outnumber the jobs
+type FileJobStore struct {
+	path    string
+	file    *os.File
+	jobs    map[string]Job
+	records int
+	mu      sync.Mutex
+}
+
+var _ JobStore = &FileJobStore{}
+
+// NewFileJobStore opens the job file, it is created if absent
+func NewFileJobStore(path string) (*FileJobStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	store := &FileJobStore{path: path, jobs: make(map[string]Job)}
+	if err := store.replay(); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	store.file = file
+	return store, nil
+}
+
+func (fs *FileJobStore) replay() error {
+	data, err := os.ReadFile(fs.path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	// a crash while appending leaves an incomplete last line, it is truncated so that the next record
+	// starts on its own line
+	if complete := bytes.LastIndexByte(data, '\n') + 1; complete < len(data) {
+		if err = os.Truncate(fs.path, int64(complete)); err != nil {
+			return err
+		}
+		data = data[:complete]
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		record := fileJobRecord{}
+		if err = json.UnmarshalJSON(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("task: job file %s line %d: %w", fs.path, line, err)
+		}
+		switch {
+		case record.Op == jobSaveOp && record.Job != nil:
+			fs.jobs[record.Job.Name] = *record.Job
+		case record.Op == jobDeleteOp:
+			delete(fs.jobs, record.Name)
+		default:
+			return fmt.Errorf("task: job file %s line %d: invalid record", fs.path, line)
+		}
+		fs.records++
+	}
+	return scanner.Err()
+}
+
+func (fs *FileJobStore) Save(job Job) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if err := fs.append(fileJobRecord{Op: jobSaveOp, Job: &job}); err != nil {
+		return err
+	}
+	fs.jobs[job.Name] = job
+	return fs.compactIfNeeded()
+}
+
+func (fs *FileJobStore) Delete(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, ok := fs.jobs[name]; !ok {
+		return nil
+	}
+	if err := fs.append(fileJobRecord{Op: jobDeleteOp, Name: name}); err != nil {
+		return err
+	}
+	delete(fs.jobs, name)
+	return fs.compactIfNeeded()
+}
+
+func (fs *FileJobStore) Load() ([]Job, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	jobs := make([]Job, 0, len(fs.jobs))
+	for _, job := range fs.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		if jobs[i].Time == jobs[j].Time {
+			return jobs[i].Name < jobs[j].Name
+		}
+		return jobs[i].Time < jobs[j].Time
+	})
+	return jobs, nil
+}
+
+// Compact rewrites the file with the current jobs only
+func (fs *FileJobStore) Compact() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.compact()
+}
+
+func (fs *FileJobStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.file.Close()
+}
+
+func (fs *FileJobStore) append(record fileJobRecord) error {
+	data, err := json.MarshalJSON(record)
+	if err != nil {
+		return err
+	}
+	if _, err = fs.file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+	fs.records++
+	return nil
+}
+
+func (fs *FileJobStore) compactIfNeeded() error {
+	if fs.records-len(fs.jobs) < compactThreshold || fs.records < 2*len(fs.jobs) {
+		return nil
+	}
+	return fs.compact()
+}
+
+func (fs *FileJobStore) compact() error {
+	var buffer bytes.Buffer
+	for _, job := range fs.jobs {
+		job := job
+		data, err := json.MarshalJSON(fileJobRecord{Op: jobSaveOp, Job: &job})
+		if err != nil {
+			return err
+		}
+		buffer.Write(data)
+		buffer.WriteByte('\n')
+	}
+	tmp := fs.path + ".tmp"
+	if err := writeFileSync(tmp, buffer.Bytes()); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, fs.path); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(fs.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	_ = fs.file.Close()
+	fs.file = file
+	fs.records = len(fs.jobs)
+	return nil
+}
+
+// writeFileSync writes the file and flushes it to the disk before it is renamed
+func writeFileSync(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,308 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+func TestFileJobStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	store, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 100; i++ {
+		_ = store.Save(Job{Name: fmt.Sprint("job-", i), Handler: "echo", Payload: []byte("payload"), Time: int64(100 - i)})
+	}
+	for i := 0; i < 98; i++ {
+		_ = store.Delete(fmt.Sprint("job-", i))
+	}
+	_ = store.Delete("unknown")
+	_ = store.Close()
+
+	data, _ := os.ReadFile(path)
+	// the deleted jobs were compacted
+	if actualValue := strings.Count(string(data), "\n"); actualValue >= 100 {
+		t.Errorf("Got %v expected less than %v records", actualValue, 100)
+	}
+	reopened, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	jobs, _ := reopened.Load()
+	if actualValue := len(jobs); actualValue != 2 {
+		t.Fatalf("Got %v expected %v", actualValue, 2)
+	}
+	if actualValue := jobs[0].Name; actualValue != "job-99" {
+		t.Errorf("Got %v expected %v", actualValue, "job-99")
+	}
+	if actualValue := string(jobs[1].Payload); actualValue != "payload" {
+		t.Errorf("Got %v expected %v", actualValue, "payload")
+	}
+}
+
+func TestFileJobStoreTornLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	store, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = store.Save(Job{Name: "job", Handler: "echo", Time: 100})
+	_ = store.Close()
+
+	// a crash while appending leaves an incomplete last line
+	file, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	_, _ = file.WriteString(`{"op":"save","job":{"name":"torn"`)
+	_ = file.Close()
+	reopened, err := NewFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = reopened.Save(Job{Name: "next", Handler: "echo", Time: 200})
+	_ = reopened.Close()
+	reopened, err = NewFileJobStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	jobs, _ := reopened.Load()
+	if actualValue := len(jobs); actualValue != 2 {
+		t.Fatalf("Got %v expected %v", actualValue, 2)
+	}
+	if actualValue := jobs[0].Name + " " + jobs[1].Name; actualValue != "job next" {
+		t.Errorf("Got %v expected %v", actualValue, "job next")
+	}
+
+	// a corrupt complete line is an error
+	_ = os.WriteFile(path, []byte("{\"op\":\"save\"\n{\"op\":\"delete\",\"name\":\"job\"}\n"), 0o644)
+	if _, err = NewFileJobStore(path); err == nil {
+		t.Errorf("Got %v expected an error", err)
+	}
+}
+
+func TestJobReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	store, _ := NewFileJobStore(path)
+	scheduler := NewTimeScheduler("jobs", 10, 100, 2, WithJobStore(store))
+	scheduler.RegisterHandler("echo", func(ctx context.Context, payload []byte) error {
+		return nil
+	})
+	scheduler.Start(context.Background())
+	if _, err := scheduler.ScheduleJob(Job{Name: "unknown", Handler: "unknown"}); err == nil {
+		t.Errorf("Got %v expected an error", err)
+	}
+	now := time.Now().UnixMilli()
+	_, _ = scheduler.ScheduleJob(Job{Name: "late", Handler: "echo", Payload: []byte("late"), Time: now + 100})
+	_, _ = scheduler.ScheduleJob(Job{Name: "skipped", Handler: "echo", Time: now + 100, Misfire: MisfireSkip})
+	_, _ = scheduler.ScheduleJob(Job{Name: "hourly", Handler: "echo", Cron: "@hourly"})
+	cancelled, _ := scheduler.ScheduleJob(Job{Name: "cancelled", Handler: "echo", Time: now + 100})
+	cancelled.Cancel()
+	scheduler.Close()
+	_ = store.Close()
+
+	// the jobs are overdue after the restart
+	time.Sleep(150 * time.Millisecond)
+	store, _ = NewFileJobStore(path)
+	defer store.Close()
+	restarted := NewTimeScheduler("jobs", 10, 100, 2, WithJobStore(store))
+	payloads := make(chan string, 10)
+	restarted.RegisterHandler("echo", func(ctx context.Context, payload []byte) error {
+		payloads <- string(payload)
+		return nil
+	})
+	restarted.Start(context.Background())
+	defer restarted.Close()
+	select {
+	case payload := <-payloads:
+		if payload != "late" {
+			t.Errorf("Got %v expected %v", payload, "late")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the overdue job did not run")
+	}
+	time.Sleep(50 * time.Millisecond)
+	jobs, _ := store.Load()
+	if actualValue := len(jobs); actualValue != 1 || jobs[0].Name != "hourly" {
+		t.Errorf("Got %v expected %v", jobs, "the hourly job")
+	}
+	if actualValue := len(payloads); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+}
+
+func TestJobDuplicate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	store, _ := NewFileJobStore(path)
+	defer store.Close()
+	scheduler := NewTimeScheduler("jobs", 10, 100, 2, WithJobStore(store))
+	payloads := make(chan string, 10)
+	scheduler.RegisterHandler("echo", func(ctx context.Context, payload []byte) error {
+		payloads <- string(payload)
+		return nil
+	})
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	// the allowed duplicates of the scheduler do not apply to the jobs, the store keeps one job by name
+	now := time.Now().UnixMilli()
+	first, _ := scheduler.ScheduleJob(Job{Name: "job", Handler: "echo", Payload: []byte("first"), Time: now + 50})
+	second, _ := scheduler.ScheduleJob(Job{Name: "job", Handler: "echo", Payload: []byte("second"), Time: now + 50})
+	if actualValue := first.Result(); actualValue != ErrCancelled {
+		t.Errorf("Got %v expected %v", actualValue, ErrCancelled)
+	}
+	jobs, _ := store.Load()
+	if len(jobs) != 1 || string(jobs[0].Payload) != "second" {
+		t.Errorf("Got %v expected %v", jobs, "the second job")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := second.Wait(ctx); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+	if actualValue := <-payloads; actualValue != "second" {
+		t.Errorf("Got %v expected %v", actualValue, "second")
+	}
+	if actualValue := len(payloads); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+	if jobs, _ = store.Load(); len(jobs) != 0 {
+		t.Errorf("Got %v expected %v", jobs, "no job")
+	}
+}
+
+type failingJobStore struct {
+	JobStore
+}
+
+func (fs failingJobStore) Save(job Job) error {
+	return errors.New("failing store")
+}
+
+func TestJobRefusedDuplicate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	store, _ := NewFileJobStore(path)
+	defer store.Close()
+	scheduler := NewTimeScheduler("jobs", 10, 100, 2, WithJobStore(store), WithDuplicatePolicy(DuplicateRefuse))
+	defer scheduler.Close()
+	scheduler.RegisterHandler("echo", func(ctx context.Context, payload []byte) error {
+		return nil
+	})
+
+	// the store keeps the job of the only accepted submission
+	var accepted []string
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(payload string) {
+			defer wg.Done()
+			_, err := scheduler.ScheduleJob(Job{Name: "job", Handler: "echo", Payload: []byte(payload), Time: 1000})
+			if err == nil {
+				mu.Lock()
+				accepted = append(accepted, payload)
+				mu.Unlock()
+			} else if err != ErrDuplicate {
+				t.Errorf("Got %v expected %v", err, ErrDuplicate)
+			}
+		}(fmt.Sprint("payload-", i))
+	}
+	wg.Wait()
+	if len(accepted) != 1 {
+		t.Fatalf("Got %v expected one accepted job", accepted)
+	}
+	jobs, _ := store.Load()
+	if len(jobs) != 1 || string(jobs[0].Payload) != accepted[0] {
+		t.Errorf("Got %v expected %v", jobs, accepted[0])
+	}
+
+	// a job which cannot be saved is not scheduled
+	failing := NewTimeScheduler("failing", 10, 100, 2, WithJobStore(failingJobStore{store}))
+	defer failing.Close()
+	failing.RegisterHandler("echo", func(ctx context.Context, payload []byte) error {
+		return nil
+	})
+	if _, err := failing.ScheduleJob(Job{Name: "job", Handler: "echo", Time: 1000}); err == nil {
+		t.Errorf("Got %v expected an error", err)
+	}
+	if _, ok := failing.Get("job"); ok {
+		t.Errorf("Got %v expected %v", ok, false)
+	}
+}
+
+// awaitNext waits until the next occurrence of the pending task is at the time
+func awaitNext(t *testing.T, scheduler *TimeScheduler, name string, at int64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		for _, info := range scheduler.List() {
+			if info.Name == name && info.Next.UnixMilli() == at {
+				return
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Got %v expected %v at %v", scheduler.List(), name, at)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestJobRetry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	store, _ := NewFileJobStore(path)
+	scheduler := NewTimeScheduler("jobs", 10, 100, 2, WithJobStore(store))
+	scheduler.RegisterHandler("echo", func(ctx context.Context, payload []byte) error {
+		return nil
+	})
+	retry := &RetryPolicy{MaxAttempts: 3, InitialDelay: 100}
+	_, _ = scheduler.ScheduleJob(Job{Name: "retried", Handler: "echo", Time: 100, Retry: retry})
+	scheduler.Close()
+	_ = store.Close()
+
+	// the retry policy is persisted with the job
+	store, _ = NewFileJobStore(path)
+	defer store.Close()
+	jobs, _ := store.Load()
+	if len(jobs) != 1 || jobs[0].Retry == nil || jobs[0].Retry.MaxAttempts != 3 || jobs[0].Retry.InitialDelay != 100 {
+		t.Fatalf("Got %v expected %v", jobs, "the retried job")
+	}
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	restarted := NewTimeScheduler("jobs", 10, 100, 2, WithJobStore(store), WithClock(fakeClock))
+	attempts := make(chan int64, 10)
+	restarted.RegisterHandler("echo", func(ctx context.Context, payload []byte) error {
+		attempts <- fakeClock.Now().UnixMilli()
+		if len(attempts) < 3 {
+			return errors.New("failure")
+		}
+		return nil
+	})
+	restarted.Start(context.Background())
+	defer restarted.Close()
+	awaitNext(t, restarted, "retried", 100)
+	for _, at := range []int64{100, 200, 400} {
+		fakeClock.Set(time.UnixMilli(at))
+		if at < 400 {
+			awaitNext(t, restarted, "retried", 2*at)
+		}
+	}
+	// the job is deleted once it succeeded
+	deadline := time.Now().Add(time.Second)
+	for jobs, _ = store.Load(); len(jobs) != 0; jobs, _ = store.Load() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Got %v expected %v", jobs, "no job")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if actualValue := len(attempts); actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+}
@@ -0,0 +1,192 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/meshware/suit-kit-golang/pkg/lifecycle"
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+const (
+	schedulerCreated int32 = iota
+	schedulerStarted
+	schedulerStopped
+)
+
+// ErrStopped the result of a task which is added to, or abandoned by, a stopped scheduler
+var ErrStopped = errors.New("task: scheduler stopped")
+
+// ShutdownError reports the tasks which did not run when the scheduler stopped
+type ShutdownError struct {
+	// Abandoned the tasks which were scheduled, they complete with ErrStopped
+	Abandoned []string
+	// Interrupted the tasks which were still running at the deadline of the stop, their context is cancelled
+	Interrupted []string
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("task: scheduler stopped with %d abandoned and %d interrupted tasks", len(e.Abandoned), len(e.Interrupted))
+}
+
+var _ lifecycle.Full = &TimeScheduler{}
+
+// WithRunDueOnStop runs the tasks which are due when the scheduler stops, instead of abandoning them
+func WithRunDueOnStop() SchedulerOption {
+	return func(ts *TimeScheduler) {
+		ts.runDueOnStop = true
+	}
+}
+
+// Init loads the persisted jobs, Start calls it if it was not called
+func (ts *TimeScheduler) Init(ctx context.Context) error {
+	var err error
+	ts.initOnce.Do(func() {
+		err = ts.loadJobs()
+	})
+	return err
+}
+
+// Start runs the scheduler and schedules the persisted jobs, a stopped scheduler cannot be started again
+func (ts *TimeScheduler) Start(ctx context.Context) error {
+	if err := ts.Init(ctx); err != nil {
+		return err
+	}
+	ts.stateMu.Lock()
+	switch ts.state {
+	case schedulerStarted:
+		ts.stateMu.Unlock()
+		return nil
+	case schedulerStopped:
+		ts.stateMu.Unlock()
+		return ErrStopped
+	}
+	ts.state = schedulerStarted
+	ts.stateMu.Unlock()
+	ts.info("Starting scheduler", log.Int("workers", ts.workerThreads))
+	go ts.processQueue()
+	ts.workers.Add(ts.workerThreads)
+	for i := 0; i < ts.workerThreads; i++ {
+		go ts.processWorking()
+	}
+	ts.submitJobs()
+	return nil
+}
+
+// Stop refuses the new tasks, runs the due tasks with WithRunDueOnStop, and waits for the running tasks
+// until the context is done. The tasks which did not run are reported by a ShutdownError.
+func (ts *TimeScheduler) Stop(ctx context.Context) error {
+	ts.stateMu.Lock()
+	previous := ts.state
+	ts.state = schedulerStopped
+	ts.stateMu.Unlock()
+	if previous == schedulerStopped {
+		return nil
+	}
+	ts.info("Stopping scheduler", log.Int64("pending", atomic.LoadInt64(&ts.tasks)))
+
+	var interrupted []string
+	if previous == schedulerStarted {
+		close(ts.stopCh)
+		select {
+		case <-ts.queueDone:
+		case <-ctx.Done():
+			ts.cancelFunc()
+			<-ts.queueDone
+		}
+		// the workers drain the due tasks, or leave after their current run
+		if ts.runDueOnStop {
+			close(ts.working)
+		} else {
+			close(ts.haltCh)
+		}
+		workersDone := make(chan struct{})
+		go func() {
+			ts.workers.Wait()
+			close(workersDone)
+		}()
+		select {
+		case <-workersDone:
+		case <-ctx.Done():
+			interrupted = ts.running()
+			ts.cancelFunc()
+		}
+	} else {
+		close(ts.queueDone)
+	}
+	ts.cancelFunc()
+
+	ts.drain()
+	ts.inflightMu.Lock()
+	abandoned := ts.abandoned
+	ts.abandoned = nil
+	ts.inflightMu.Unlock()
+	if len(abandoned) > 0 || len(interrupted) > 0 {
+		ts.warn("Scheduler stopped", log.Int("abandoned", len(abandoned)), log.Int("interrupted", len(interrupted)))
+		return &ShutdownError{Abandoned: abandoned, Interrupted: interrupted}
+	}
+	ts.info("Scheduler stopped")
+	return nil
+}
+
+// Close stops the scheduler at once, the context of the running tasks is cancelled
+func (ts *TimeScheduler) Close() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = ts.Stop(ctx)
+}
+
+// running returns the names of the running tasks
+func (ts *TimeScheduler) running() []string {
+	ts.inflightMu.Lock()
+	defer ts.inflightMu.Unlock()
+	names := make([]string, 0, len(ts.inflight))
+	for _, name := range ts.inflight {
+		names = append(names, name)
+	}
+	return names
+}
+
+// drain abandons the tasks left in the wheel and the queues of a stopped scheduler
+func (ts *TimeScheduler) drain() {
+	arms, _, cancels := ts.take()
+	for _, tw := range cancels {
+		tw.remove()
+	}
+	ts.timeWheel.drain(ts.abandon)
+	for _, tw := range ts.held {
+		ts.abandon(tw)
+	}
+	ts.held = nil
+	for _, o := range arms {
+		ts.abandon(o.work)
+	}
+	// the workers which outlived the deadline may still take the queued occurrences
+	for {
+		select {
+		case o, ok := <-ts.working:
+			if !ok {
+				return
+			}
+			ts.abandon(o.work)
+		default:
+			return
+		}
+	}
+}
+
+// abandon completes the task with ErrStopped, a persisted job stays in the store
+func (ts *TimeScheduler) abandon(tw *TimeWork) {
+	if !atomic.CompareAndSwapInt32(&tw.state, INIT, CANCELLED) {
+		return
+	}
+	ts.unregister(tw)
+	atomic.AddInt64(&ts.tasks, -1)
+	ts.inflightMu.Lock()
+	ts.abandoned = append(ts.abandoned, tw.name)
+	ts.inflightMu.Unlock()
+	ts.debug("Task abandoned", log.String("task", tw.name))
+	tw.complete(ErrStopped)
+}
@@ -0,0 +1,170 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStop(t *testing.T) {
+	scheduler := NewTimeScheduler("stop", 10, 100, 1, WithRunDueOnStop())
+	if err := scheduler.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	started := make(chan struct{})
+	release := make(chan struct{})
+	slow := scheduler.DelayFunc("slow", 0, func(ctx context.Context) error {
+		close(started)
+		<-release
+		return nil
+	})
+	<-started
+	// the due task waits for the only worker
+	due := scheduler.DelayFunc("due", 0, func(ctx context.Context) error {
+		return nil
+	})
+	later := scheduler.DelayFunc("later", 10000, func(ctx context.Context) error {
+		return nil
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	time.AfterFunc(50*time.Millisecond, func() {
+		close(release)
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := scheduler.Stop(ctx)
+	var shutdownError *ShutdownError
+	if !errors.As(err, &shutdownError) {
+		t.Fatalf("Got %v expected a ShutdownError", err)
+	}
+	if actualValue := shutdownError.Abandoned; !reflect.DeepEqual(actualValue, []string{"later"}) {
+		t.Errorf("Got %v expected %v", actualValue, []string{"later"})
+	}
+	if actualValue := len(shutdownError.Interrupted); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+	for _, future := range []Future{slow, due} {
+		if actualValue := future.Result(); actualValue != nil {
+			t.Errorf("Got %v expected %v", actualValue, nil)
+		}
+	}
+	if actualValue := later.Result(); actualValue != ErrStopped {
+		t.Errorf("Got %v expected %v", actualValue, ErrStopped)
+	}
+	if actualValue := scheduler.Stats().Pending; actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+
+	// the stopped scheduler refuses the tasks
+	if actualValue := scheduler.DelayFunc("refused", 0, func(ctx context.Context) error {
+		return nil
+	}).Result(); actualValue != ErrStopped {
+		t.Errorf("Got %v expected %v", actualValue, ErrStopped)
+	}
+	if actualValue := scheduler.Start(context.Background()); actualValue != ErrStopped {
+		t.Errorf("Got %v expected %v", actualValue, ErrStopped)
+	}
+	if actualValue := scheduler.Stop(ctx); actualValue != nil {
+		t.Errorf("Got %v expected %v", actualValue, nil)
+	}
+}
+
+func TestStopDeadline(t *testing.T) {
+	scheduler := NewTimeScheduler("deadline", 10, 100, 1)
+	_ = scheduler.Start(context.Background())
+	started := make(chan struct{})
+	stuck := scheduler.DelayFunc("stuck", 0, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+	due := scheduler.DelayFunc("due", 0, func(ctx context.Context) error {
+		return nil
+	})
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := scheduler.Stop(ctx)
+	var shutdownError *ShutdownError
+	if !errors.As(err, &shutdownError) {
+		t.Fatalf("Got %v expected a ShutdownError", err)
+	}
+	if actualValue := shutdownError.Interrupted; !reflect.DeepEqual(actualValue, []string{"stuck"}) {
+		t.Errorf("Got %v expected %v", actualValue, []string{"stuck"})
+	}
+	// the due task is not run without WithRunDueOnStop
+	if actualValue := shutdownError.Abandoned; !reflect.DeepEqual(actualValue, []string{"due"}) {
+		t.Errorf("Got %v expected %v", actualValue, []string{"due"})
+	}
+	if actualValue := due.Result(); actualValue != ErrStopped {
+		t.Errorf("Got %v expected %v", actualValue, ErrStopped)
+	}
+	if actualValue := stuck.Wait(context.Background()); actualValue != context.Canceled {
+		t.Errorf("Got %v expected %v", actualValue, context.Canceled)
+	}
+}
+
+func TestStopDeadlineQueued(t *testing.T) {
+	for _, options := range [][]SchedulerOption{nil, {WithRunDueOnStop()}} {
+		scheduler := NewTimeScheduler("queued", 10, 100, 2, options...)
+		_ = scheduler.Start(context.Background())
+		// the slow tasks hold the workers past the deadline of the stop, they ignore their context
+		var started sync.WaitGroup
+		started.Add(2)
+		release := make(chan struct{})
+		for _, name := range []string{"slow1", "slow2"} {
+			scheduler.DelayFunc(name, 0, func(ctx context.Context) error {
+				started.Done()
+				<-release
+				return nil
+			})
+		}
+		started.Wait()
+		var futures []Future
+		for i := 0; i < 50; i++ {
+			futures = append(futures, scheduler.DelayFunc(fmt.Sprintf("due%d", i), 0, func(ctx context.Context) error {
+				time.Sleep(100 * time.Microsecond)
+				return nil
+			}))
+		}
+		deadline := time.Now().Add(time.Second)
+		for scheduler.Stats().Queued < 50 && time.Now().Before(deadline) {
+			time.Sleep(time.Millisecond)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		time.AfterFunc(19*time.Millisecond, func() {
+			close(release)
+		})
+		stopped := make(chan error)
+		go func() {
+			stopped <- scheduler.Stop(ctx)
+		}()
+		select {
+		case err := <-stopped:
+			// the workers are released at the deadline, they take the queued tasks while the stop drains them
+			var shutdownError *ShutdownError
+			if err != nil && !errors.As(err, &shutdownError) {
+				t.Errorf("Got %v expected a ShutdownError", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Got a blocked stop expected the deadline")
+		}
+		cancel()
+		// the queued tasks are abandoned, or were run by the released workers
+		waitCtx, waitCancel := context.WithTimeout(context.Background(), time.Second)
+		for _, future := range futures {
+			if actualValue := future.Wait(waitCtx); actualValue != nil && actualValue != ErrStopped {
+				t.Errorf("Got %v expected %v", actualValue, ErrStopped)
+			}
+		}
+		waitCancel()
+	}
+}
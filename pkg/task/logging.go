@@ -0,0 +1,33 @@
+package task
+
+import (
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+func (ts *TimeScheduler) debug(msg string, fields ...log.Field) {
+	if ts.logger != nil {
+		ts.logger.Debug(msg, ts.withScheduler(fields)...)
+	}
+}
+
+func (ts *TimeScheduler) info(msg string, fields ...log.Field) {
+	if ts.logger != nil {
+		ts.logger.Info(msg, ts.withScheduler(fields)...)
+	}
+}
+
+func (ts *TimeScheduler) warn(msg string, fields ...log.Field) {
+	if ts.logger != nil {
+		ts.logger.Warn(msg, ts.withScheduler(fields)...)
+	}
+}
+
+func (ts *TimeScheduler) error(msg string, fields ...log.Field) {
+	if ts.logger != nil {
+		ts.logger.Error(msg, ts.withScheduler(fields)...)
+	}
+}
+
+func (ts *TimeScheduler) withScheduler(fields []log.Field) []log.Field {
+	return append(fields, log.String("scheduler", ts.prefix))
+}
@@ -0,0 +1,72 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+type syncBuffer struct {
+	buffer bytes.Buffer
+	mu     sync.Mutex
+}
+
+func (sb *syncBuffer) Write(p []byte) (int, error) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buffer.Write(p)
+}
+
+func (sb *syncBuffer) String() string {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	return sb.buffer.String()
+}
+
+// bufferLogger logs at the level to the buffer only, unlike log.New which also writes to the console
+func bufferLogger(buffer *syncBuffer, level log.Level) *log.Logger {
+	return log.NewTee([]log.TeeOption{{Out: buffer, LevelEnablerFunc: func(l log.Level) bool {
+		return l >= level
+	}}})
+}
+
+func runLogged(logger *log.Logger) {
+	scheduler := NewTimeScheduler("logged", 10, 100, 1, WithLogger(logger))
+	scheduler.Start(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	future := scheduler.DelayFunc("job", 0, func(ctx context.Context) error {
+		panic("boom")
+	})
+	_ = future.Wait(ctx)
+	scheduler.Close()
+}
+
+func TestLogging(t *testing.T) {
+	info := &syncBuffer{}
+	runLogged(bufferLogger(info, log.InfoLevel))
+	for _, expected := range []string{`"msg":"Starting scheduler"`, `"msg":"Task panicked"`, `"panic":"boom"`, `"scheduler":"logged"`} {
+		if !strings.Contains(info.String(), expected) {
+			t.Errorf("Got %v expected %v", info.String(), expected)
+		}
+	}
+	if strings.Contains(info.String(), "Task running") {
+		t.Errorf("Got %v expected no debug log", info.String())
+	}
+
+	debug := &syncBuffer{}
+	runLogged(bufferLogger(debug, log.DebugLevel))
+	for _, expected := range []string{`"msg":"Task running","task":"job","lateness":`, `"msg":"Task completed"`} {
+		if !strings.Contains(debug.String(), expected) {
+			t.Errorf("Got %v expected %v", debug.String(), expected)
+		}
+	}
+
+	// a nil logger silences the scheduler
+	runLogged(nil)
+}
@@ -0,0 +1,190 @@
+package task
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+// DefaultBuckets the upper bounds of the lateness and duration histograms
+var DefaultBuckets = []time.Duration{
+	time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond, 50 * time.Millisecond,
+	100 * time.Millisecond, 500 * time.Millisecond, time.Second, 5 * time.Second, 10 * time.Second, time.Minute,
+}
+
+// Stats a snapshot of the activity of a scheduler
+type Stats struct {
+	// Pending the tasks scheduled and not done, a recurring task counts once
+	Pending int64
+	// Queued the due runs waiting for a worker
+	Queued int
+	// Backlog the additions waiting for the queue, TryAdd fails when it reaches the queue capacity
+	Backlog int
+	// Running the runs in progress
+	Running   int64
+	Executed  uint64
+	Cancelled uint64
+	// Failed the runs which returned an error, Panicked the runs which panicked
+	Failed   uint64
+	Panicked uint64
+	// Retried the failed runs which were re-armed by their retry policy
+	Retried uint64
+	// Misfired the late occurrences skipped or dropped by their misfire policy
+	Misfired uint64
+	// Lateness the delay between the scheduled time and the start of the runs
+	Lateness Histogram
+	// Duration the duration of the runs
+	Duration Histogram
+}
+
+// Histogram a snapshot of a histogram, the counts are cumulative and the last one is for +Inf
+type Histogram struct {
+	Bounds []time.Duration
+	Counts []uint64
+	Count  uint64
+	Sum    time.Duration
+}
+
+type histogram struct {
+	bounds []time.Duration
+	counts []uint64
+	sum    int64
+}
+
+func newHistogram(bounds []time.Duration) *histogram {
+	return &histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+}
+
+func (h *histogram) observe(d time.Duration) {
+	i := 0
+	for i < len(h.bounds) && d > h.bounds[i] {
+		i++
+	}
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddInt64(&h.sum, int64(d))
+}
+
+func (h *histogram) snapshot() Histogram {
+	snapshot := Histogram{Bounds: h.bounds, Counts: make([]uint64, len(h.counts)), Sum: time.Duration(atomic.LoadInt64(&h.sum))}
+	for i := range h.counts {
+		snapshot.Count += atomic.LoadUint64(&h.counts[i])
+		snapshot.Counts[i] = snapshot.Count
+	}
+	return snapshot
+}
+
+type metrics struct {
+	running                                                  int64
+	executed, cancelled, failed, panicked, retried, misfired uint64
+	lateness, duration                                       *histogram
+}
+
+func newMetrics() metrics {
+	return metrics{lateness: newHistogram(DefaultBuckets), duration: newHistogram(DefaultBuckets)}
+}
+
+// track measures a run which started at the time of the clock
+func (ts *TimeScheduler) track(name string, at int64) func(err error) {
+	start := ts.clock.Now()
+	lateness := time.Duration(start.UnixMilli()-at) * time.Millisecond
+	if lateness < 0 {
+		lateness = 0
+	}
+	ts.metrics.lateness.observe(lateness)
+	ts.debug("Task running", log.String("task", name), log.Duration("lateness", lateness))
+	atomic.AddInt64(&ts.metrics.running, 1)
+	ts.inflightMu.Lock()
+	ts.runs++
+	id := ts.runs
+	ts.inflight[id] = name
+	ts.inflightMu.Unlock()
+	return func(err error) {
+		ts.inflightMu.Lock()
+		delete(ts.inflight, id)
+		ts.inflightMu.Unlock()
+		duration := ts.clock.Since(start)
+		ts.debug("Task completed", log.String("task", name), log.Duration("duration", duration))
+		ts.metrics.duration.observe(duration)
+		atomic.AddInt64(&ts.metrics.running, -1)
+		atomic.AddUint64(&ts.metrics.executed, 1)
+		if err != nil {
+			var panicError *PanicError
+			if errors.As(err, &panicError) {
+				atomic.AddUint64(&ts.metrics.panicked, 1)
+			} else {
+				atomic.AddUint64(&ts.metrics.failed, 1)
+			}
+			ts.report(name, err)
+		}
+	}
+}
+
+// Stats returns a snapshot of the activity of the scheduler
+func (ts *TimeScheduler) Stats() Stats {
+	return Stats{
+		Pending:   atomic.LoadInt64(&ts.tasks),
+		Queued:    len(ts.working),
+		Backlog:   ts.backlog(),
+		Running:   atomic.LoadInt64(&ts.metrics.running),
+		Executed:  atomic.LoadUint64(&ts.metrics.executed),
+		Cancelled: atomic.LoadUint64(&ts.metrics.cancelled),
+		Failed:    atomic.LoadUint64(&ts.metrics.failed),
+		Panicked:  atomic.LoadUint64(&ts.metrics.panicked),
+		Retried:   atomic.LoadUint64(&ts.metrics.retried),
+		Misfired:  atomic.LoadUint64(&ts.metrics.misfired),
+		Lateness:  ts.metrics.lateness.snapshot(),
+		Duration:  ts.metrics.duration.snapshot(),
+	}
+}
+
+// WritePrometheus writes the stats of the scheduler in the Prometheus text format
+func (ts *TimeScheduler) WritePrometheus(w io.Writer) error {
+	return ts.Stats().WritePrometheus(w, ts.prefix)
+}
+
+// WritePrometheus writes the stats in the Prometheus text format, labelled with the scheduler name
+func (s Stats) WritePrometheus(w io.Writer, scheduler string) error {
+	pw := &prometheusWriter{w: w, label: fmt.Sprintf("scheduler=%q", scheduler)}
+	pw.metric("task_scheduler_pending", "gauge", "Tasks scheduled and not done.", float64(s.Pending))
+	pw.metric("task_scheduler_queued", "gauge", "Due runs waiting for a worker.", float64(s.Queued))
+	pw.metric("task_scheduler_backlog", "gauge", "Additions waiting for the queue.", float64(s.Backlog))
+	pw.metric("task_scheduler_running", "gauge", "Runs in progress.", float64(s.Running))
+	pw.metric("task_scheduler_executed_total", "counter", "Runs executed.", float64(s.Executed))
+	pw.metric("task_scheduler_cancelled_total", "counter", "Tasks cancelled.", float64(s.Cancelled))
+	pw.metric("task_scheduler_failed_total", "counter", "Runs which returned an error.", float64(s.Failed))
+	pw.metric("task_scheduler_panicked_total", "counter", "Runs which panicked.", float64(s.Panicked))
+	pw.metric("task_scheduler_retried_total", "counter", "Failed runs which were retried.", float64(s.Retried))
+	pw.metric("task_scheduler_misfired_total", "counter", "Late occurrences skipped or dropped.", float64(s.Misfired))
+	pw.histogram("task_scheduler_lateness_seconds", "Delay between the scheduled time and the start of the runs.", s.Lateness)
+	pw.histogram("task_scheduler_duration_seconds", "Duration of the runs.", s.Duration)
+	return pw.err
+}
+
+type prometheusWriter struct {
+	w     io.Writer
+	label string
+	err   error
+}
+
+func (pw *prometheusWriter) printf(format string, args ...interface{}) {
+	if pw.err == nil {
+		_, pw.err = fmt.Fprintf(pw.w, format, args...)
+	}
+}
+
+func (pw *prometheusWriter) metric(name, kind, help string, value float64) {
+	pw.printf("# HELP %s %s\n# TYPE %s %s\n%s{%s} %g\n", name, help, name, kind, name, pw.label, value)
+}
+
+func (pw *prometheusWriter) histogram(name, help string, h Histogram) {
+	pw.printf("# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	for i, bound := range h.Bounds {
+		pw.printf("%s_bucket{%s,le=\"%g\"} %d\n", name, pw.label, bound.Seconds(), h.Counts[i])
+	}
+	pw.printf("%s_bucket{%s,le=\"+Inf\"} %d\n", name, pw.label, h.Count)
+	pw.printf("%s_sum{%s} %g\n%s_count{%s} %d\n", name, pw.label, h.Sum.Seconds(), name, pw.label, h.Count)
+}
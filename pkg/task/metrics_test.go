@@ -0,0 +1,81 @@
+package task
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+func TestStats(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Time{})
+	scheduler := NewTimeScheduler("stats", 10, 100, 2, WithClock(fakeClock))
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	ok := scheduler.DelayFunc("ok", 100, func(ctx context.Context) error {
+		return nil
+	})
+	failed := scheduler.DelayFunc("failed", 100, func(ctx context.Context) error {
+		return errors.New("failure")
+	})
+	panicked := scheduler.DelayFunc("panicked", 100, func(ctx context.Context) error {
+		panic("boom")
+	})
+	scheduler.Delay("cancelled", 100, func() {}).Cancel()
+	scheduler.Delay("pending", 10000, func() {})
+
+	// the runs start 2s late
+	fakeClock.Advance(2 * time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	for _, future := range []Future{ok, failed, panicked} {
+		_ = future.Wait(ctx)
+	}
+
+	stats := scheduler.Stats()
+	if actualValue := stats.Pending; actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+	if actualValue := stats.Executed; actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+	if actualValue := stats.Cancelled; actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+	if stats.Failed != 1 || stats.Panicked != 1 {
+		t.Errorf("Got %v and %v expected %v", stats.Failed, stats.Panicked, 1)
+	}
+	// 1.9s of lateness falls in the 5s bucket
+	if stats.Lateness.Counts[6] != 0 || stats.Lateness.Counts[7] != 3 {
+		t.Errorf("Got %v expected the runs between 1s and 5s", stats.Lateness.Counts)
+	}
+	if actualValue := stats.Lateness.Sum; actualValue != 3*1900*time.Millisecond {
+		t.Errorf("Got %v expected %v", actualValue, 3*1900*time.Millisecond)
+	}
+	if actualValue := stats.Duration.Count; actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+
+	var buffer bytes.Buffer
+	if err := scheduler.WritePrometheus(&buffer); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"# TYPE task_scheduler_pending gauge",
+		`task_scheduler_pending{scheduler="stats"} 1`,
+		`task_scheduler_executed_total{scheduler="stats"} 3`,
+		`task_scheduler_lateness_seconds_bucket{scheduler="stats",le="1"} 0`,
+		`task_scheduler_lateness_seconds_bucket{scheduler="stats",le="5"} 3`,
+		`task_scheduler_lateness_seconds_bucket{scheduler="stats",le="+Inf"} 3`,
+		`task_scheduler_lateness_seconds_sum{scheduler="stats"} 5.7`,
+	} {
+		if !strings.Contains(buffer.String(), line+"\n") {
+			t.Errorf("Got %v expected the line %v", buffer.String(), line)
+		}
+	}
+}
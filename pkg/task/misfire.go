@@ -0,0 +1,115 @@
+package task
+
+import (
+	"errors"
+	"sync/atomic"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+// ErrMisfired the result of a task dropped by its misfire policy
+var ErrMisfired = errors.New("task: misfired")
+
+// fireAllLimit caps the missed occurrences which fire back to back with MisfireFireAll
+const fireAllLimit = 100
+
+// MisfirePolicy decides how an occurrence is processed when it is due later than expected, because the
+// scheduler was stopped, paused or overloaded
+type MisfirePolicy string
+
+const (
+	// MisfireFireOnce runs the late occurrence once, the missed occurrences of a recurring task are
+	// coalesced into it. It is the default.
+	MisfireFireOnce MisfirePolicy = "fire-once"
+	// MisfireFireAll runs the late occurrence, then every missed occurrence of a recurring task back to
+	// back. Beyond 100 missed occurrences the following ones are coalesced into one run. A fixed-delay task
+	// fires once.
+	MisfireFireAll MisfirePolicy = "fire-all"
+	// MisfireSkip skips the occurrence later than the misfire threshold, a one-shot task is dropped and a
+	// recurring task waits for its next time
+	MisfireSkip MisfirePolicy = "skip"
+	// MisfireDrop drops the task, one-shot or recurring, when an occurrence is later than the misfire
+	// threshold
+	MisfireDrop MisfirePolicy = "drop"
+)
+
+// WithMisfire applies the misfire policy to the late occurrences of the task
+func WithMisfire(policy MisfirePolicy) TaskOption {
+	return func(tw *TimeWork) {
+		tw.misfire = policy
+	}
+}
+
+// WithMisfireThreshold sets the lateness in milliseconds beyond which an occurrence is skipped or dropped,
+// the threshold is at least the tick of the scheduler
+func WithMisfireThreshold(threshold int64) TaskOption {
+	return func(tw *TimeWork) {
+		tw.threshold = threshold
+	}
+}
+
+// misfired returns true if the occurrence is skipped or dropped by the misfire policy
+func (tw *TimeWork) misfired(lateness, tick int64) bool {
+	if tw.misfire != MisfireSkip && tw.misfire != MisfireDrop {
+		return false
+	}
+	threshold := tw.threshold
+	if threshold < tick {
+		threshold = tick
+	}
+	return lateness > threshold
+}
+
+// following returns the time of the occurrence after the one at the time, the missed occurrences are
+// coalesced unless they all fire
+func (tw *TimeWork) following(at, now int64) int64 {
+	if tw.misfire == MisfireFireAll {
+		return tw.next(at, at)
+	}
+	return tw.next(at, now)
+}
+
+// coalesce returns the time of the last missed occurrence, the late occurrence at the time runs for it
+// unless the missed occurrences all fire. It counts the missed occurrences which fire back to back.
+func (tw *TimeWork) coalesce(at, now int64) int64 {
+	if tw.misfire == MisfireFireAll {
+		if following := tw.next(at, at); following >= 0 && following <= now {
+			tw.missed++
+		} else {
+			tw.missed = 0
+		}
+		if tw.missed <= fireAllLimit {
+			return at
+		}
+		tw.missed = 0
+	}
+	for next := tw.next(at, now); next >= 0 && next <= now; next = tw.next(next, now) {
+		at = next
+	}
+	return at
+}
+
+// misfire skips the late occurrence, the task waits for its first time after the current time or is dropped
+func (ts *TimeScheduler) misfire(tw *TimeWork, at, currentTime int64) {
+	atomic.AddUint64(&ts.metrics.misfired, 1)
+	next := int64(-1)
+	if tw.next != nil && tw.misfire == MisfireSkip {
+		for next = tw.next(at, currentTime); next >= 0 && next <= currentTime; {
+			next = tw.next(next, currentTime)
+		}
+	}
+	if next < 0 {
+		ts.info("Task misfired, dropping", log.String("task", tw.name), log.Int64("time", at))
+		if atomic.CompareAndSwapInt32(&tw.state, INIT, EXPIRED) {
+			if tw.afterRun != nil {
+				tw.afterRun(tw)
+			}
+			tw.complete(ErrMisfired)
+		}
+		return
+	}
+	ts.info("Task misfired, skipping", log.String("task", tw.name), log.Int64("time", at),
+		log.Int64("next", next))
+	tw.setTime(next)
+	ts.schedule(tw, currentTime)
+}
@@ -0,0 +1,119 @@
+package task
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+func TestMisfire(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("misfire", 10, 100, 4, WithClock(fakeClock))
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	counters := make(map[MisfirePolicy]*int32)
+	for _, policy := range []MisfirePolicy{MisfireFireOnce, MisfireFireAll, MisfireSkip} {
+		counter := new(int32)
+		counters[policy] = counter
+		scheduler.ScheduleAtFixedRate(string(policy), 100, 100, func() {
+			atomic.AddInt32(counter, 1)
+		}, WithMisfire(policy))
+	}
+	dropped := scheduler.ScheduleAtFixedRate("drop", 100, 100, func() {}, WithMisfire(MisfireDrop))
+	skipped := scheduler.DelayFunc("skipped", 100, func(ctx context.Context) error {
+		return nil
+	}, WithMisfire(MisfireSkip))
+	tolerated := scheduler.DelayFunc("tolerated", 100, func(ctx context.Context) error {
+		return nil
+	}, WithMisfire(MisfireDrop), WithMisfireThreshold(500))
+	time.Sleep(20 * time.Millisecond)
+
+	// the occurrences at 100, 200 and 300 are missed
+	fakeClock.Set(time.UnixMilli(350))
+	time.Sleep(50 * time.Millisecond)
+	for policy, expected := range map[MisfirePolicy]int32{MisfireFireOnce: 1, MisfireFireAll: 3, MisfireSkip: 0} {
+		if actualValue := atomic.LoadInt32(counters[policy]); actualValue != expected {
+			t.Errorf("%s: Got %v expected %v", policy, actualValue, expected)
+		}
+	}
+	if !dropped.IsExpired() {
+		t.Errorf("Got %v expected %v", false, true)
+	}
+	if actualValue := skipped.Result(); actualValue != ErrMisfired {
+		t.Errorf("Got %v expected %v", actualValue, ErrMisfired)
+	}
+	if actualValue := tolerated.Result(); actualValue != nil {
+		t.Errorf("Got %v expected %v", actualValue, nil)
+	}
+	if actualValue := scheduler.Stats().Misfired; actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+
+	// the skipping task goes on at its next time
+	fakeClock.Set(time.UnixMilli(400))
+	time.Sleep(50 * time.Millisecond)
+	if actualValue := atomic.LoadInt32(counters[MisfireSkip]); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
+
+// awaitCount waits until the counter reaches the value
+func awaitCount(t *testing.T, counter *int32, expected int32) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for atomic.LoadInt32(counter) < expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("Got %v expected %v", atomic.LoadInt32(counter), expected)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if actualValue := atomic.LoadInt32(counter); actualValue != expected {
+		t.Errorf("Got %v expected %v", actualValue, expected)
+	}
+}
+
+func TestFireAllLimit(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("fire-all", 10, 100, 4, WithClock(fakeClock))
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	// 1000 occurrences are missed, the first ones fire back to back and the others are coalesced
+	var runs int32
+	scheduler.ScheduleAtFixedRate("every-millisecond", 1, 1, func() {
+		atomic.AddInt32(&runs, 1)
+	}, WithMisfire(MisfireFireAll))
+	fakeClock.Set(time.UnixMilli(1000))
+	awaitNext(t, scheduler, "every-millisecond", 1001)
+	awaitCount(t, &runs, fireAllLimit+1)
+}
+
+func TestCronMisfire(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("cron-misfire", 10, 100, 4, WithClock(fakeClock))
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	counters := make(map[MisfirePolicy]*int32)
+	for _, policy := range []MisfirePolicy{MisfireFireOnce, MisfireFireAll, MisfireSkip} {
+		counter := new(int32)
+		counters[policy] = counter
+		_, _ = scheduler.Cron(string(policy), "* * * * * *", func() {
+			atomic.AddInt32(counter, 1)
+		}, WithMisfire(policy))
+	}
+
+	// the fire times at 1s, 2s and 3s are missed
+	fakeClock.Set(time.UnixMilli(3500))
+	for policy, expected := range map[MisfirePolicy]int32{MisfireFireOnce: 1, MisfireFireAll: 3, MisfireSkip: 0} {
+		awaitNext(t, scheduler, string(policy), 4000)
+		awaitCount(t, counters[policy], expected)
+	}
+	if actualValue := scheduler.Stats().Misfired; actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
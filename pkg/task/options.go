@@ -0,0 +1,31 @@
+package task
+
+import (
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+// SchedulerOption configures a TimeScheduler
+type SchedulerOption func(*TimeScheduler)
+
+// WithJobStore persists the jobs of the scheduler in the store, they are reloaded on Start
+func WithJobStore(store JobStore) SchedulerOption {
+	return func(ts *TimeScheduler) {
+		ts.store = store
+	}
+}
+
+// WithClock measures the time of the scheduler with the clock, the real clock by default
+func WithClock(c clock.Clock) SchedulerOption {
+	return func(ts *TimeScheduler) {
+		ts.clock = c
+	}
+}
+
+// WithLogger logs the activity of the scheduler to the logger, the runs are logged at debug level. The
+// scheduler is silent without logger.
+func WithLogger(logger *log.Logger) SchedulerOption {
+	return func(ts *TimeScheduler) {
+		ts.logger = logger
+	}
+}
@@ -0,0 +1,121 @@
+package task
+
+import (
+	"sync/atomic"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+// Pause holds the due occurrences until Resume, the time keeps advancing and the runs already queued for
+// the workers complete
+func (ts *TimeScheduler) Pause() {
+	if atomic.CompareAndSwapInt32(&ts.paused, 0, 1) {
+		ts.info("Pausing scheduler")
+		ts.nudge()
+	}
+}
+
+// Resume dispatches the held occurrences, their lateness is processed by the misfire policy of their task
+func (ts *TimeScheduler) Resume() {
+	if atomic.CompareAndSwapInt32(&ts.paused, 1, 0) {
+		ts.info("Resuming scheduler")
+		ts.nudge()
+	}
+}
+
+// IsPaused returns true between Pause and Resume
+func (ts *TimeScheduler) IsPaused() bool {
+	return atomic.LoadInt32(&ts.paused) == 1
+}
+
+// Pause holds the due occurrences of the pending task until Resume, it returns false if the task is done
+// or already paused
+func (tw *TimeWork) Pause() bool {
+	return atomic.LoadInt32(&tw.state) == INIT && atomic.CompareAndSwapInt32(&tw.paused, 0, 1)
+}
+
+// Resume dispatches the held occurrence of the task, it returns false if the task is not paused
+func (tw *TimeWork) Resume() bool {
+	if !atomic.CompareAndSwapInt32(&tw.paused, 1, 0) {
+		return false
+	}
+	if tw.wakeup != nil {
+		tw.wakeup()
+	}
+	return true
+}
+
+func (tw *TimeWork) IsPaused() bool {
+	return atomic.LoadInt32(&tw.paused) == 1
+}
+
+// nudge wakes the queue up to release the held tasks
+func (ts *TimeScheduler) nudge() {
+	select {
+	case ts.wake <- struct{}{}:
+	default:
+	}
+}
+
+// hold keeps the due task of a paused scheduler or task out of the wheels, it returns false if the task
+// can be dispatched
+func (ts *TimeScheduler) hold(tw *TimeWork) bool {
+	if !ts.IsPaused() && !tw.IsPaused() {
+		return false
+	}
+	if !tw.held && atomic.LoadInt32(&tw.state) == INIT {
+		if len(ts.held) == cap(ts.held) {
+			ts.prune()
+		}
+		tw.held = true
+		ts.held = append(ts.held, tw)
+		ts.debug("Task held", log.String("task", tw.name), log.Int64("time", tw.at()))
+	}
+	return true
+}
+
+// release schedules the held tasks which are no longer paused, the misfire policies apply to the late ones
+func (ts *TimeScheduler) release(currentTime int64) {
+	if len(ts.held) == 0 || ts.IsPaused() {
+		return
+	}
+	held := ts.held
+	ts.held = nil
+	for _, tw := range held {
+		if !tw.held {
+			continue
+		}
+		if atomic.LoadInt32(&tw.state) != INIT {
+			tw.held = false
+			continue
+		}
+		if tw.IsPaused() {
+			ts.held = append(ts.held, tw)
+			continue
+		}
+		tw.held = false
+		ts.schedule(tw, currentTime)
+	}
+}
+
+// prune drops the held tasks which were cancelled or moved before the held tasks grow, like the wheel
+// ignores the cancelled tasks
+func (ts *TimeScheduler) prune() {
+	held := ts.held[:0]
+	kept := make(map[*TimeWork]bool, len(ts.held))
+	for _, tw := range ts.held {
+		if !tw.held || kept[tw] {
+			continue
+		}
+		if atomic.LoadInt32(&tw.state) != INIT {
+			tw.held = false
+			continue
+		}
+		kept[tw] = true
+		held = append(held, tw)
+	}
+	for i := len(held); i < len(ts.held); i++ {
+		ts.held[i] = nil
+	}
+	ts.held = held
+}
@@ -0,0 +1,125 @@
+package task
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+func TestPause(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("pause", 10, 100, 2, WithClock(fakeClock))
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	var runs int32
+	scheduler.ScheduleAtFixedRate("rate", 100, 100, func() {
+		atomic.AddInt32(&runs, 1)
+	})
+	skipped := scheduler.DelayFunc("skipped", 150, func(ctx context.Context) error {
+		return nil
+	}, WithMisfire(MisfireSkip))
+	time.Sleep(20 * time.Millisecond)
+
+	scheduler.Pause()
+	if !scheduler.IsPaused() {
+		t.Errorf("Got %v expected %v", false, true)
+	}
+	fakeClock.Set(time.UnixMilli(350))
+	time.Sleep(50 * time.Millisecond)
+	if actualValue := atomic.LoadInt32(&runs); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+	if actualValue := skipped.Result(); actualValue != ErrNotDone {
+		t.Errorf("Got %v expected %v", actualValue, ErrNotDone)
+	}
+
+	// the missed occurrences fire once, the late one-shot task is skipped
+	scheduler.Resume()
+	time.Sleep(50 * time.Millisecond)
+	if actualValue := atomic.LoadInt32(&runs); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+	if actualValue := skipped.Result(); actualValue != ErrMisfired {
+		t.Errorf("Got %v expected %v", actualValue, ErrMisfired)
+	}
+}
+
+func TestPauseTask(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("pause-task", 10, 100, 2, WithClock(fakeClock))
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	var paused, running int32
+	timeout := scheduler.ScheduleAtFixedRate("paused", 100, 100, func() {
+		atomic.AddInt32(&paused, 1)
+	})
+	scheduler.ScheduleAtFixedRate("running", 100, 100, func() {
+		atomic.AddInt32(&running, 1)
+	})
+	if !timeout.Pause() || timeout.Pause() {
+		t.Errorf("Got %v expected the first pause only", timeout.IsPaused())
+	}
+	if info := scheduler.List()[0]; info.Name != "paused" || !info.Paused {
+		t.Errorf("Got %v expected %v", info, "the paused task")
+	}
+	time.Sleep(20 * time.Millisecond)
+	for _, now := range []int64{100, 200, 300} {
+		fakeClock.Set(time.UnixMilli(now))
+		time.Sleep(20 * time.Millisecond)
+	}
+	if actualValue := atomic.LoadInt32(&paused); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+	if actualValue := atomic.LoadInt32(&running); actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+
+	if !timeout.Resume() || timeout.Resume() {
+		t.Errorf("Got %v expected the first resume only", timeout.IsPaused())
+	}
+	time.Sleep(20 * time.Millisecond)
+	if actualValue := atomic.LoadInt32(&paused); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+	// the resumed task goes on at its rate
+	fakeClock.Set(time.UnixMilli(400))
+	time.Sleep(20 * time.Millisecond)
+	if actualValue := atomic.LoadInt32(&paused); actualValue != 2 {
+		t.Errorf("Got %v expected %v", actualValue, 2)
+	}
+}
+
+func TestPauseCancelledHeld(t *testing.T) {
+	scheduler := NewTimeScheduler("pause-cancel", 10, 100, 1)
+	scheduler.Pause()
+	live := newTimeWork("live", 0, func(ctx context.Context) error {
+		return nil
+	}, nil, nil)
+	scheduler.hold(live)
+	// the tasks cancelled during the pause do not pile up
+	for i := 0; i < 1000; i++ {
+		tw := newTimeWork("cancelled", 0, func(ctx context.Context) error {
+			return nil
+		}, nil, nil)
+		scheduler.hold(tw)
+		tw.Cancel()
+	}
+	if actualValue := len(scheduler.held); actualValue > 2 {
+		t.Errorf("Got %v expected at most %v", actualValue, 2)
+	}
+	if scheduler.held[0] != live {
+		t.Errorf("Got %v expected %v", scheduler.held[0].name, live.name)
+	}
+	live.Pause()
+	live.Cancel()
+	scheduler.Resume()
+	scheduler.release(0)
+	if actualValue := len(scheduler.held); actualValue != 0 {
+		t.Errorf("Got %v expected %v", actualValue, 0)
+	}
+}
@@ -0,0 +1,56 @@
+package task
+
+// TaskOption configures a task
+type TaskOption func(*TimeWork)
+
+// WithoutOverlap prevents overlapping executions of a recurring task. A fixed-rate occurrence that is due
+// while the previous one is running starts once it completes, and the missed occurrences are coalesced
+// into that single late run. Fixed-delay tasks never overlap.
+func WithoutOverlap() TaskOption {
+	return func(tw *TimeWork) {
+		tw.serial = true
+	}
+}
+
+// ScheduleAtFixedRate runs the runnable after the initial delay, then every period in milliseconds
+// regardless of how long the runs take, until the returned Timeout is cancelled. When the scheduler falls
+// behind, the missed occurrences are coalesced into one run.
+func (ts *TimeScheduler) ScheduleAtFixedRate(name string, initialDelay, period int64, runnable func(),
+	opts ...TaskOption) Timeout {
+	if runnable == nil || period <= 0 {
+		return nil
+	}
+	tw := newTimeWork(name, ts.now()+initialDelay, runnableFunc(runnable), ts.afterRun, ts.afterCancel)
+	tw.next = func(last, now int64) int64 {
+		next := last + period
+		for next+period <= now {
+			next += period
+		}
+		return next
+	}
+	for _, opt := range opts {
+		opt(tw)
+	}
+	return ts.add(tw)
+}
+
+// ScheduleWithFixedDelay runs the runnable after the initial delay, then waits the delay in milliseconds
+// after each completion before the next run, until the returned Timeout is cancelled
+func (ts *TimeScheduler) ScheduleWithFixedDelay(name string, initialDelay, delay int64, runnable func(),
+	opts ...TaskOption) Timeout {
+	if runnable == nil || delay < 0 {
+		return nil
+	}
+	tw := newTimeWork(name, ts.now()+initialDelay, runnableFunc(runnable), ts.afterRun, ts.afterCancel)
+	tw.next = func(last, now int64) int64 {
+		return now + delay
+	}
+	for _, opt := range opts {
+		opt(tw)
+	}
+	tw.serial = true
+	if tw.misfire == MisfireFireAll {
+		tw.misfire = MisfireFireOnce
+	}
+	return ts.add(tw)
+}
@@ -0,0 +1,173 @@
+package task
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+// runRecorder records the start of the runs of a task on a fake clock, a run lasts until the clock reaches
+// its end
+type runRecorder struct {
+	clock   *clock.FakeClock
+	starts  []int64
+	ends    map[int64]int
+	overlap bool
+	mu      sync.Mutex
+}
+
+func newRunRecorder(fakeClock *clock.FakeClock) *runRecorder {
+	return &runRecorder{clock: fakeClock, ends: make(map[int64]int)}
+}
+
+func (r *runRecorder) run(duration int64) func() {
+	return func() {
+		start := r.clock.Now().UnixMilli()
+		r.mu.Lock()
+		r.overlap = r.overlap || len(r.ends) > 0
+		r.starts = append(r.starts, start)
+		r.ends[start+duration]++
+		r.mu.Unlock()
+		for r.clock.Now().UnixMilli() < start+duration {
+			time.Sleep(time.Millisecond)
+		}
+		r.mu.Lock()
+		if r.ends[start+duration]--; r.ends[start+duration] == 0 {
+			delete(r.ends, start+duration)
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *runRecorder) runs() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.starts...)
+}
+
+// settled reports whether the expected runs started, the runs which are over returned, and the task waits
+// for its next occurrence unless it runs
+func (r *runRecorder) settled(scheduler *TimeScheduler, name string, now int64, started int) bool {
+	r.mu.Lock()
+	running := len(r.ends) > 0
+	for end := range r.ends {
+		if end <= now {
+			r.mu.Unlock()
+			return false
+		}
+	}
+	if len(r.starts) < started {
+		r.mu.Unlock()
+		return false
+	}
+	r.mu.Unlock()
+	if running {
+		return true
+	}
+	for _, info := range scheduler.List() {
+		if info.Name == name && info.Next.UnixMilli() <= now {
+			return false
+		}
+	}
+	return true
+}
+
+// advance moves the clock by steps of 10 milliseconds until the time, and waits at each step for the
+// scheduler to settle, then checks the starts of the runs
+func (r *runRecorder) advance(t *testing.T, scheduler *TimeScheduler, name string, until int64, expected []int64) {
+	t.Helper()
+	for now := r.clock.Now().UnixMilli(); now <= until; now += 10 {
+		r.clock.Set(time.UnixMilli(now))
+		started := 0
+		for started < len(expected) && expected[started] <= now {
+			started++
+		}
+		deadline := time.Now().Add(time.Second)
+		for !r.settled(scheduler, name, now, started) {
+			if time.Now().After(deadline) {
+				t.Fatalf("Got %v expected %v at %v", r.runs(), expected[:started], now)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if actualValue := fmt.Sprint(r.runs()); actualValue != fmt.Sprint(expected) {
+		t.Errorf("Got %v expected %v", actualValue, fmt.Sprint(expected))
+	}
+}
+
+func TestScheduleAtFixedRate(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("rate", 10, 100, 4, WithClock(fakeClock))
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	// the cadence is kept while the runs take longer than the period
+	recorder := newRunRecorder(fakeClock)
+	timeout := scheduler.ScheduleAtFixedRate("rate", 0, 50, recorder.run(120))
+	recorder.advance(t, scheduler, "rate", 500, []int64{0, 50, 100, 150, 200, 250, 300, 350, 400, 450, 500})
+	timeout.Cancel()
+	if !recorder.overlap {
+		t.Errorf("Got %v expected %v", recorder.overlap, true)
+	}
+
+	// the occurrences due while the serial task runs are coalesced into one late run
+	serial := newRunRecorder(fakeClock)
+	timeout = scheduler.ScheduleAtFixedRate("serial", 0, 50, serial.run(120), WithoutOverlap())
+	serial.advance(t, scheduler, "serial", 1000, []int64{500, 620, 740, 860, 980})
+	timeout.Cancel()
+	if serial.overlap {
+		t.Errorf("Got %v expected %v", serial.overlap, false)
+	}
+	if scheduler.ScheduleAtFixedRate("invalid", 0, 0, func() {}) != nil {
+		t.Errorf("Got a timeout expected %v", nil)
+	}
+}
+
+func TestScheduleWithFixedDelay(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("delay", 10, 100, 4, WithClock(fakeClock))
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	// the delay is waited after the completion of each run
+	recorder := newRunRecorder(fakeClock)
+	timeout := scheduler.ScheduleWithFixedDelay("delay", 20, 50, recorder.run(30))
+	recorder.advance(t, scheduler, "delay", 400, []int64{20, 100, 180, 260, 340})
+	if !timeout.Cancel() {
+		t.Errorf("Got %v expected %v", false, true)
+	}
+	if _, ok := scheduler.Get("delay"); ok {
+		t.Errorf("Got %v expected %v", ok, false)
+	}
+	recorder.advance(t, scheduler, "delay", 500, []int64{20, 100, 180, 260, 340})
+}
+
+func TestFixedDelayAfterCompletion(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("completion", 10, 100, 2, WithClock(fakeClock))
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	// the run completes within the tenth millisecond, the next run waits the whole delay after it
+	scheduler.ScheduleWithFixedDelay("completion", 0, 50, func() {
+		fakeClock.Set(time.Unix(0, 10500*int64(time.Microsecond)))
+	})
+	deadline := time.Now().Add(time.Second)
+	for {
+		info := scheduler.List()
+		if len(info) == 1 && info[0].Next.UnixMilli() > 0 {
+			if actualValue := info[0].Next.UnixMilli(); actualValue != 61 {
+				t.Errorf("Got %v expected %v", actualValue, 61)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Got %v expected the next run", info)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,156 @@
+package task
+
+import (
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+var (
+	// ErrNotFound no pending task has the name
+	ErrNotFound = errors.New("task: not found")
+	// ErrDuplicate the result of a task refused because a pending task has the same name
+	ErrDuplicate = errors.New("task: duplicate name")
+)
+
+// DuplicatePolicy decides what happens when a task is added with the name of a pending task
+type DuplicatePolicy string
+
+const (
+	// DuplicateAllow keeps both tasks, the name refers to the last one. It is the default.
+	DuplicateAllow DuplicatePolicy = "allow"
+	// DuplicateRefuse completes the new task with ErrDuplicate
+	DuplicateRefuse DuplicatePolicy = "refuse"
+	// DuplicateReplace cancels the pending task
+	DuplicateReplace DuplicatePolicy = "replace"
+)
+
+// WithDuplicatePolicy applies the policy to the tasks added with the name of a pending task
+func WithDuplicatePolicy(policy DuplicatePolicy) SchedulerOption {
+	return func(ts *TimeScheduler) {
+		ts.duplicates = policy
+	}
+}
+
+// TaskInfo describes a pending task
+type TaskInfo struct {
+	Name string
+	// Next the time of the next occurrence
+	Next time.Time
+	// Recurring is true for the cron and periodic tasks
+	Recurring bool
+	Paused    bool
+}
+
+// Get returns the pending task with the name
+func (ts *TimeScheduler) Get(name string) (Future, bool) {
+	ts.registryMu.RLock()
+	defer ts.registryMu.RUnlock()
+	tw, ok := ts.registry[name]
+	if !ok {
+		return nil, false
+	}
+	return tw, true
+}
+
+// List returns the pending tasks by time of their next occurrence
+func (ts *TimeScheduler) List() []TaskInfo {
+	ts.registryMu.RLock()
+	infos := make([]TaskInfo, 0, len(ts.registry))
+	for name, tw := range ts.registry {
+		infos = append(infos, TaskInfo{Name: name, Next: time.UnixMilli(tw.at()), Recurring: tw.next != nil, Paused: tw.IsPaused()})
+	}
+	ts.registryMu.RUnlock()
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Next.Equal(infos[j].Next) {
+			return infos[i].Name < infos[j].Name
+		}
+		return infos[i].Next.Before(infos[j].Next)
+	})
+	return infos
+}
+
+// Reschedule moves the next occurrence of the pending task with the name to the time in milliseconds, a
+// queued occurrence which did not start yet is dropped
+func (ts *TimeScheduler) Reschedule(name string, time int64) error {
+	ts.registryMu.RLock()
+	tw, ok := ts.registry[name]
+	ts.registryMu.RUnlock()
+	if !ok || atomic.LoadInt32(&tw.state) != INIT {
+		return ErrNotFound
+	}
+	ts.stateMu.RLock()
+	defer ts.stateMu.RUnlock()
+	if ts.state == schedulerStopped {
+		return ErrStopped
+	}
+	ts.postMove(occurrence{work: tw, time: time})
+	return nil
+}
+
+// register records the task by name, it returns ErrDuplicate if the task is refused as a duplicate. The
+// task is persisted while its name is reserved, it is not registered if it cannot be persisted.
+func (ts *TimeScheduler) register(tw *TimeWork) error {
+	if len(tw.name) == 0 {
+		return nil
+	}
+	policy := ts.duplicates
+	if len(tw.duplicates) > 0 {
+		policy = tw.duplicates
+	}
+	ts.registryMu.Lock()
+	existing, ok := ts.registry[tw.name]
+	if ok && policy == DuplicateRefuse {
+		ts.registryMu.Unlock()
+		return ErrDuplicate
+	}
+	if tw.persist != nil {
+		if err := tw.persist(); err != nil {
+			ts.registryMu.Unlock()
+			return err
+		}
+	}
+	ts.registry[tw.name] = tw
+	ts.registryMu.Unlock()
+	if ok && policy == DuplicateReplace {
+		ts.debug("Task replaced", log.String("task", tw.name))
+		existing.Cancel()
+	}
+	return nil
+}
+
+// unregister forgets the task, unless the name refers to another task
+func (ts *TimeScheduler) unregister(tw *TimeWork) {
+	ts.registryMu.Lock()
+	defer ts.registryMu.Unlock()
+	if ts.registry[tw.name] == tw {
+		delete(ts.registry, tw.name)
+	}
+}
+
+// replaced returns true if the name of the task refers to another task
+func (ts *TimeScheduler) replaced(tw *TimeWork) bool {
+	ts.registryMu.RLock()
+	defer ts.registryMu.RUnlock()
+	other, ok := ts.registry[tw.name]
+	return ok && other != tw
+}
+
+// move applies a reschedule, the queued occurrences and placements of the task become stale
+func (ts *TimeScheduler) move(o occurrence, currentTime int64) {
+	tw := o.work
+	if atomic.LoadInt32(&tw.state) != INIT {
+		return
+	}
+	tw.mu.Lock()
+	tw.setTime(o.time)
+	atomic.AddUint64(&tw.generation, 1)
+	tw.mu.Unlock()
+	tw.remove()
+	tw.held = false
+	ts.debug("Task rescheduled", log.String("task", tw.name), log.Int64("time", o.time))
+	ts.schedule(tw, currentTime)
+}
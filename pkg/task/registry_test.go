@@ -0,0 +1,104 @@
+package task
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+func TestRegistry(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("registry", 10, 100, 2, WithClock(fakeClock))
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	runs := make(chan string, 10)
+	first := scheduler.Delay("first", 1000, func() {
+		runs <- "first"
+	})
+	scheduler.ScheduleAtFixedRate("rate", 500, 500, func() {
+		runs <- "rate"
+	})
+	if future, ok := scheduler.Get("first"); !ok || future != first {
+		t.Errorf("Got %v expected %v", future, first)
+	}
+	if _, ok := scheduler.Get("unknown"); ok {
+		t.Errorf("Got %v expected %v", ok, false)
+	}
+	expected := []TaskInfo{
+		{Name: "rate", Next: time.UnixMilli(500), Recurring: true},
+		{Name: "first", Next: time.UnixMilli(1000)},
+	}
+	for i, info := range scheduler.List() {
+		if !info.Next.Equal(expected[i].Next) || info.Name != expected[i].Name || info.Recurring != expected[i].Recurring {
+			t.Errorf("Got %v expected %v", info, expected[i])
+		}
+	}
+
+	// the rescheduled tasks run at their new time only
+	if err := scheduler.Reschedule("first", 200); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduler.Reschedule("rate", 2000); err != nil {
+		t.Fatal(err)
+	}
+	if err := scheduler.Reschedule("unknown", 2000); err != ErrNotFound {
+		t.Errorf("Got %v expected %v", err, ErrNotFound)
+	}
+	time.Sleep(20 * time.Millisecond)
+	fakeClock.Advance(1500 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := first.(Future).Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if actualValue := len(runs); actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+	if _, ok := scheduler.Get("first"); ok {
+		t.Errorf("Got %v expected %v", ok, false)
+	}
+	if actualValue := scheduler.List(); len(actualValue) != 1 || !actualValue[0].Next.Equal(time.UnixMilli(2000)) {
+		t.Errorf("Got %v expected %v", actualValue, time.UnixMilli(2000))
+	}
+}
+
+func TestDuplicatePolicy(t *testing.T) {
+	refusing := NewTimeScheduler("refuse", 10, 100, 1, WithDuplicatePolicy(DuplicateRefuse))
+	_ = refusing.Start(context.Background())
+	defer refusing.Close()
+	kept := refusing.DelayFunc("job", 10000, func(ctx context.Context) error {
+		return nil
+	})
+	refused := refusing.DelayFunc("job", 10000, func(ctx context.Context) error {
+		return nil
+	})
+	if actualValue := refused.Result(); actualValue != ErrDuplicate {
+		t.Errorf("Got %v expected %v", actualValue, ErrDuplicate)
+	}
+	if future, _ := refusing.Get("job"); future != kept {
+		t.Errorf("Got %v expected %v", future, kept)
+	}
+
+	replacing := NewTimeScheduler("replace", 10, 100, 1, WithDuplicatePolicy(DuplicateReplace))
+	_ = replacing.Start(context.Background())
+	defer replacing.Close()
+	replaced := replacing.DelayFunc("job", 10000, func(ctx context.Context) error {
+		return nil
+	})
+	replacement := replacing.DelayFunc("job", 10000, func(ctx context.Context) error {
+		return nil
+	})
+	if actualValue := replaced.Result(); actualValue != ErrCancelled {
+		t.Errorf("Got %v expected %v", actualValue, ErrCancelled)
+	}
+	if future, _ := replacing.Get("job"); future != replacement {
+		t.Errorf("Got %v expected %v", future, replacement)
+	}
+	if actualValue := replacing.Stats().Pending; actualValue != 1 {
+		t.Errorf("Got %v expected %v", actualValue, 1)
+	}
+}
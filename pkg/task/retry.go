@@ -0,0 +1,106 @@
+package task
+
+import (
+	"math"
+	"math/rand"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
+
+// RetryPolicy re-arms the failed runs of a task with an exponential backoff
+type RetryPolicy struct {
+	// MaxAttempts the runs of an occurrence including the first one
+	MaxAttempts int `json:"maxAttempts"`
+	// InitialDelay the delay in milliseconds before the first retry
+	InitialDelay int64 `json:"initialDelay,omitempty"`
+	// Multiplier the growth of the delay after each retry, 2 by default
+	Multiplier float64 `json:"multiplier,omitempty"`
+	// Max caps the delay in milliseconds, the delay is not capped if it is zero
+	Max int64 `json:"max,omitempty"`
+	// Jitter draws each delay at random between zero and the computed delay
+	Jitter bool `json:"jitter,omitempty"`
+	// GiveUp is called with the last error once the attempts of an occurrence are exhausted, it is not
+	// persisted with a job
+	GiveUp func(name string, err error, attempts int) `json:"-"`
+}
+
+// WithRetry retries the runs which return an error or panic. A one-shot task completes with the last
+// error once it gives up, a recurring task goes on with its next occurrence and never overlaps.
+func WithRetry(policy RetryPolicy) TaskOption {
+	return func(tw *TimeWork) {
+		if policy.MaxAttempts > 1 {
+			tw.retry = &policy
+		}
+	}
+}
+
+// delay returns the delay in milliseconds before the retry following the attempt
+func (p *RetryPolicy) delay(attempt int) int64 {
+	multiplier := p.Multiplier
+	if multiplier <= 0 {
+		multiplier = 2
+	}
+	delay := float64(p.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if p.Max > 0 && delay > float64(p.Max) {
+		delay = float64(p.Max)
+	}
+	if p.Jitter && delay >= 1 {
+		return rand.Int63n(int64(delay) + 1)
+	}
+	return int64(delay)
+}
+
+// retry re-arms the failed occurrence which started at the time, it returns false once the occurrence
+// succeeded or gave up. The next occurrence of a recurring task follows the one which was retried.
+func (ts *TimeScheduler) retry(tw *TimeWork, at int64, generation uint64, err error) bool {
+	if err == nil {
+		ts.restore(tw, generation)
+		return false
+	}
+	if tw.attempts == 0 {
+		tw.origin = at
+	}
+	tw.attempts++
+	if tw.attempts >= tw.retry.MaxAttempts {
+		ts.warn("Task gave up", log.String("task", tw.name), log.Int("attempts", tw.attempts),
+			log.String("error", err.Error()))
+		ts.giveUp(tw, err)
+		ts.restore(tw, generation)
+		return false
+	}
+	delay := tw.retry.delay(tw.attempts)
+	atomic.AddUint64(&ts.metrics.retried, 1)
+	ts.debug("Task retrying", log.String("task", tw.name), log.Int("attempt", tw.attempts),
+		log.Duration("delay", time.Duration(delay)*time.Millisecond))
+	ts.requeue(tw, generation, ts.now()+delay)
+	return true
+}
+
+// giveUp calls the GiveUp of the policy, a panic of it is reported like the panic of a run
+func (ts *TimeScheduler) giveUp(tw *TimeWork, err error) {
+	if tw.retry.GiveUp == nil {
+		return
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			ts.report(tw.name, &PanicError{Value: r, Stack: debug.Stack()})
+		}
+	}()
+	tw.retry.GiveUp(tw.name, err, tw.attempts)
+}
+
+// restore resets the attempts and the time of the occurrence which was retried
+func (ts *TimeScheduler) restore(tw *TimeWork, generation uint64) {
+	if tw.attempts == 0 {
+		return
+	}
+	tw.attempts = 0
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.currentGeneration() == generation {
+		tw.setTime(tw.origin)
+	}
+}
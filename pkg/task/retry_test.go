@@ -0,0 +1,119 @@
+package task
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	policy := &RetryPolicy{InitialDelay: 100, Max: 500}
+	for attempt, expected := range []int64{100, 200, 400, 500, 500} {
+		if actualValue := policy.delay(attempt + 1); actualValue != expected {
+			t.Errorf("Got %v expected %v", actualValue, expected)
+		}
+	}
+	policy.Jitter = true
+	for i := 0; i < 100; i++ {
+		if actualValue := policy.delay(3); actualValue < 0 || actualValue > 400 {
+			t.Errorf("Got %v expected between %v and %v", actualValue, 0, 400)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.UnixMilli(0))
+	scheduler := NewTimeScheduler("retry", 10, 100, 2, WithClock(fakeClock))
+	_ = scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	failure := errors.New("failure")
+	runs := make(chan int64, 10)
+	attempts := 0
+	succeeding := scheduler.DelayFunc("succeeding", 100, func(ctx context.Context) error {
+		runs <- fakeClock.Now().UnixMilli()
+		if attempts++; attempts < 3 {
+			return failure
+		}
+		return nil
+	}, WithRetry(RetryPolicy{MaxAttempts: 5, InitialDelay: 100}))
+
+	var gaveUp []interface{}
+	failing := scheduler.DelayFunc("failing", 100, func(ctx context.Context) error {
+		return failure
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, InitialDelay: 50, GiveUp: func(name string, err error, attempts int) {
+		gaveUp = append(gaveUp, name, err, attempts)
+	}}))
+
+	// the retries wait 100ms then 200ms
+	for _, expected := range []int64{100, 200, 400} {
+		fakeClock.Set(time.UnixMilli(expected))
+		select {
+		case run := <-runs:
+			if run != expected {
+				t.Errorf("Got %v expected %v", run, expected)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("the run at %v did not happen", expected)
+		}
+		// the failed run is re-armed once it returned
+		time.Sleep(20 * time.Millisecond)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if actualValue := succeeding.Wait(ctx); actualValue != nil {
+		t.Errorf("Got %v expected %v", actualValue, nil)
+	}
+	if actualValue := failing.Wait(ctx); actualValue != failure {
+		t.Errorf("Got %v expected %v", actualValue, failure)
+	}
+	if len(gaveUp) != 3 || gaveUp[0] != "failing" || gaveUp[1] != failure || gaveUp[2] != 2 {
+		t.Errorf("Got %v expected %v", gaveUp, []interface{}{"failing", failure, 2})
+	}
+	if actualValue := scheduler.Stats().Retried; actualValue != 3 {
+		t.Errorf("Got %v expected %v", actualValue, 3)
+	}
+}
+
+func TestPanickingGiveUp(t *testing.T) {
+	scheduler := NewTimeScheduler("giveup", 10, 100, 1)
+	panics := make(chan interface{}, 1)
+	scheduler.OnPanic = func(name string, err error, stack []byte) {
+		var panicError *PanicError
+		if errors.As(err, &panicError) {
+			panics <- panicError.Value
+		}
+	}
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	failure := errors.New("failure")
+	failing := scheduler.DelayFunc("failing", 0, func(ctx context.Context) error {
+		return failure
+	}, WithRetry(RetryPolicy{MaxAttempts: 2, GiveUp: func(name string, err error, attempts int) {
+		panic("giveup")
+	}}))
+	if actualValue := failing.Wait(ctx); actualValue != failure {
+		t.Errorf("Got %v expected %v", actualValue, failure)
+	}
+	select {
+	case actualValue := <-panics:
+		if actualValue != "giveup" {
+			t.Errorf("Got %v expected %v", actualValue, "giveup")
+		}
+	case <-ctx.Done():
+		t.Errorf("Got %v expected %v", nil, "giveup")
+	}
+	// the single worker survives the panic
+	ok := scheduler.DelayFunc("ok", 0, func(ctx context.Context) error {
+		return nil
+	})
+	if err := ok.Wait(ctx); err != nil {
+		t.Errorf("Got %v expected %v", err, nil)
+	}
+}
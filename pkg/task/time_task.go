@@ -1,16 +1,16 @@
 package task
 
 import (
+	"container/heap"
 	"context"
-	"log"
-	"os"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
 	"time"
-)
 
-// Logger for debugging
-var logger = log.New(os.Stdout, "TIMER: ", log.LstdFlags)
+	"github.com/meshware/suit-kit-golang/pkg/clock"
+	"github.com/meshware/suit-kit-golang/pkg/log"
+)
 
 // TimeTask defines a task with a name and execution time.
 type TimeTask interface {
@@ -49,6 +49,10 @@ type Timeout interface {
 	IsExpired() bool
 	IsCancelled() bool
 	Cancel() bool
+	// Pause holds the task until Resume, the occurrences missed meanwhile are processed by its misfire policy
+	Pause() bool
+	Resume() bool
+	IsPaused() bool
 }
 
 // Timer defines the scheduler's API.
@@ -72,16 +76,20 @@ func newTimeSlot() *TimeSlot {
 	}
 }
 
+// add returns true if the expiration of the slot changed, so the slot must be queued
 func (s *TimeSlot) add(timeWork *TimeWork, expire int64) bool {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if s.expiration == -1 {
+	timeWork.timeSlot = s
+	s.tasks = append(s.tasks, timeWork)
+	if s.expiration != expire {
 		s.expiration = expire
+		return true
 	}
-	s.tasks = append(s.tasks, timeWork)
-	return s.expiration == expire
+	return false
 }
 
+// remove keeps the expiration, the slot stays queued until it is flushed
 func (s *TimeSlot) remove(timeWork *TimeWork) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -91,9 +99,6 @@ func (s *TimeSlot) remove(timeWork *TimeWork) {
 			break
 		}
 	}
-	if len(s.tasks) == 0 {
-		s.expiration = -1
-	}
 }
 
 func (s *TimeSlot) flush(consumer func(*TimeWork)) {
@@ -101,32 +106,57 @@ func (s *TimeSlot) flush(consumer func(*TimeWork)) {
 	tasks := s.tasks
 	s.tasks = make([]*TimeWork, 0)
 	s.expiration = -1
+	for _, tw := range tasks {
+		tw.timeSlot = nil
+	}
 	s.mu.Unlock()
 	for _, tw := range tasks {
 		consumer(tw)
 	}
 }
 
-// TimeWheel manages a circular array of time slots.
+// slotQueue a min-heap of the slots by expiration, shared by the wheels of a hierarchy
+type slotQueue []*TimeSlot
+
+func (q slotQueue) Len() int            { return len(q) }
+func (q slotQueue) Less(i, j int) bool  { return q[i].expiration < q[j].expiration }
+func (q slotQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *slotQueue) Push(x interface{}) { *q = append(*q, x.(*TimeSlot)) }
+func (q *slotQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	slot := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return slot
+}
+
+// TimeWheel manages a circular array of time slots. Tasks beyond its duration are held by an overflow
+// wheel, whose tick is the duration of this wheel, and cascade down when the overflow slot expires.
 type TimeWheel struct {
 	tickTime  int64
 	ticks     int
 	duration  int64
 	now       int64
-	index     int
+	level     int
 	timeSlots []*TimeSlot
-	queue     chan *TimeSlot
+	queue     *slotQueue
+	overflow  *TimeWheel
 }
 
 func newTimeWheel(tickTime int64, ticks int, now int64) *TimeWheel {
+	return newLevelWheel(tickTime, ticks, now, 0, &slotQueue{})
+}
+
+func newLevelWheel(tickTime int64, ticks int, now int64, level int, queue *slotQueue) *TimeWheel {
 	tw := &TimeWheel{
 		tickTime:  tickTime,
 		ticks:     ticks,
 		duration:  tickTime * int64(ticks),
 		now:       now - (now % tickTime),
-		index:     0,
+		level:     level,
 		timeSlots: make([]*TimeSlot, ticks),
-		queue:     make(chan *TimeSlot, ticks),
+		queue:     queue,
 	}
 	for i := 0; i < ticks; i++ {
 		tw.timeSlots[i] = newTimeSlot()
@@ -134,54 +164,130 @@ func newTimeWheel(tickTime int64, ticks int, now int64) *TimeWheel {
 	return tw
 }
 
-func (tw *TimeWheel) getLeastOneTick(t int64) int64 {
-	result := time.Now().UnixMilli() + tw.tickTime
-	if t > result {
-		return t
+// add returns false if the task is already due at the current time
+func (tw *TimeWheel) add(timeWork *TimeWork, currentTime int64) bool {
+	at := timeWork.at()
+	if at <= currentTime {
+		return false
 	}
-	return result
-}
-
-func (tw *TimeWheel) add(timeWork *TimeWork) bool {
-	currentTime := time.Now().UnixMilli()
-	timeDiff := timeWork.time - currentTime
-	logger.Printf("Adding task %s: scheduled=%d, current=%d, diff=%d", timeWork.name, timeWork.time, currentTime, timeDiff)
-	if timeDiff <= 0 {
-		logger.Printf("Task %s is already expired, scheduling immediately", timeWork.name)
-		return false // Task is already due
+	// the slots of the finest wheel expire at the end of their tick, so that no task runs early,
+	// the slots of the overflow wheels expire at the start of their tick to cascade down in time
+	var id int64
+	var beyond bool
+	if tw.level == 0 {
+		id = (at + tw.tickTime - 1) / tw.tickTime
+		beyond = id*tw.tickTime > tw.now+tw.duration
+	} else {
+		id = at / tw.tickTime
+		beyond = id*tw.tickTime >= tw.now+tw.duration
 	}
-	slotTime := timeWork.time - (timeWork.time % tw.tickTime)
-	count := (slotTime - tw.now) / tw.tickTime
-	if count < 0 || count >= int64(tw.ticks) {
-		logger.Printf("Task %s time %d is beyond wheel duration, rejecting", timeWork.name, timeWork.time)
-		return false
+	if beyond {
+		if tw.overflow == nil {
+			tw.overflow = newLevelWheel(tw.duration, tw.ticks, tw.now, tw.level+1, tw.queue)
+		}
+		return tw.overflow.add(timeWork, currentTime)
 	}
-	slotIndex := (int(count) + tw.index) % tw.ticks
-	timeSlot := tw.timeSlots[slotIndex]
-	if timeSlot.add(timeWork, slotTime) {
-		logger.Printf("Task %s added to slot %d with expiration %d", timeWork.name, slotIndex, slotTime)
-		tw.queue <- timeSlot
+	timeSlot := tw.timeSlots[id%int64(tw.ticks)]
+	if timeSlot.add(timeWork, id*tw.tickTime) {
+		heap.Push(tw.queue, timeSlot)
 	}
 	return true
 }
 
 func (tw *TimeWheel) advance(timestamp int64) {
-	if timestamp >= tw.now+tw.tickTime {
-		tw.now = timestamp - (timestamp % tw.tickTime)
-		tw.index = int((tw.now / tw.tickTime) % int64(tw.ticks))
-		//logger.Printf("Advancing time wheel to %d, index %d", tw.now, tw.index)
+	for w := tw; w != nil; w = w.overflow {
+		if timestamp >= w.now+w.tickTime {
+			w.now = timestamp - (timestamp % w.tickTime)
+		}
 	}
 }
 
+// expire flushes the expired slots of the hierarchy, the tasks are added again to cascade down, and the
+// due tasks are passed to the consumer
+func (tw *TimeWheel) expire(currentTime int64, consumer func(*TimeWork)) {
+	tw.advance(currentTime)
+	var expired []*TimeWork
+	for tw.queue.Len() > 0 && (*tw.queue)[0].expiration <= currentTime {
+		heap.Pop(tw.queue).(*TimeSlot).flush(func(timeWork *TimeWork) {
+			expired = append(expired, timeWork)
+		})
+	}
+	for _, timeWork := range expired {
+		if !timeWork.IsCancelled() && !tw.add(timeWork, currentTime) {
+			consumer(timeWork)
+		}
+	}
+}
+
+// drain flushes the slots of the hierarchy, the tasks are passed to the consumer
+func (tw *TimeWheel) drain(consumer func(*TimeWork)) {
+	for w := tw; w != nil; w = w.overflow {
+		for _, timeSlot := range w.timeSlots {
+			timeSlot.flush(consumer)
+		}
+	}
+	*tw.queue = (*tw.queue)[:0]
+}
+
+// nextExpiration returns the expiration of the earliest queued slot
+func (tw *TimeWheel) nextExpiration() (int64, bool) {
+	if tw.queue.Len() == 0 {
+		return 0, false
+	}
+	return (*tw.queue)[0].expiration, true
+}
+
 // TimeWork represents a scheduled task.
 type TimeWork struct {
 	name        string
 	time        int64
-	runnable    func()
+	runnable    func(ctx context.Context) error
 	afterRun    func(*TimeWork)
 	afterCancel func(*TimeWork)
 	timeSlot    *TimeSlot
 	state       int32 // 0: INIT, 1: CANCELLED, 2: EXPIRED
+	// next returns the time of the occurrence after the last one, negative when there is none. It makes
+	// the task recurring, the task stays INIT until it is cancelled or its last occurrence runs.
+	next func(last, now int64) int64
+	// serial tasks arm the next occurrence once the previous one completed, with rearm
+	serial bool
+	rearm  func(tw *TimeWork, generation uint64, err error)
+	// retry re-arms the failed occurrences with backoff, attempts counts the failed runs of the occurrence
+	// at origin
+	retry    *RetryPolicy
+	backoff  func(tw *TimeWork, at int64, generation uint64, err error) bool
+	attempts int
+	origin   int64
+	// misfire processes the occurrences later than the threshold in milliseconds, missed counts the missed
+	// occurrences fired back to back by the queue
+	misfire   MisfirePolicy
+	threshold int64
+	missed    int
+	// duplicates overrides the duplicate policy of the scheduler if set, persist saves the job of the task
+	// once its name is reserved
+	duplicates DuplicatePolicy
+	persist    func() error
+	// paused tasks are held by the queue when they are due, wakeup releases them on Resume
+	paused int32
+	held   bool
+	wakeup func()
+	// generation is incremented when the task is rescheduled, mu orders it with the time of the rearm
+	generation uint64
+	mu         sync.Mutex
+	// track is called when a run starts, and the returned function when it ends, before the task completes
+	track func(name string, at int64) func(err error)
+	// done is closed with the result once the task expires or is cancelled
+	done     chan struct{}
+	err      error
+	doneOnce sync.Once
+}
+
+// occurrence a run of a task, or a placement in the wheels, stale once the generation of the task changed
+type occurrence struct {
+	work       *TimeWork
+	time       int64
+	last       bool
+	generation uint64
 }
 
 const (
@@ -190,7 +296,7 @@ const (
 	EXPIRED   = 2
 )
 
-func newTimeWork(name string, time int64, runnable func(), afterRun, afterCancel func(*TimeWork)) *TimeWork {
+func newTimeWork(name string, time int64, runnable func(ctx context.Context) error, afterRun, afterCancel func(*TimeWork)) *TimeWork {
 	return &TimeWork{
 		name:        name,
 		time:        time,
@@ -198,24 +304,89 @@ func newTimeWork(name string, time int64, runnable func(), afterRun, afterCancel
 		afterRun:    afterRun,
 		afterCancel: afterCancel,
 		state:       INIT,
+		done:        make(chan struct{}),
+	}
+}
+
+// runnableFunc adapts a runnable without context and result
+func runnableFunc(runnable func()) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		runnable()
+		return nil
 	}
 }
 
 func (tw *TimeWork) Run() {
-	currentTime := time.Now().UnixMilli()
-	if tw.time > currentTime {
-		logger.Printf("Task %s not yet due: scheduled=%d, current=%d, delaying", tw.name, tw.time, currentTime)
-		time.Sleep(time.Duration(tw.time-currentTime) * time.Millisecond)
+	tw.run(context.Background(), tw.at(), tw.currentGeneration(), true)
+}
+
+// run executes the occurrence at the time, the last one expires the task
+func (tw *TimeWork) run(ctx context.Context, at int64, generation uint64, last bool) {
+	if !last {
+		if atomic.LoadInt32(&tw.state) == INIT {
+			err := tw.call(ctx, at)
+			if tw.serial && tw.rearm != nil && !tw.retried(at, generation, err) {
+				tw.rearm(tw, generation, err)
+			}
+		}
+		return
+	}
+	if tw.retry != nil {
+		// the task stays INIT while it is retried
+		if atomic.LoadInt32(&tw.state) != INIT {
+			return
+		}
+		err := tw.call(ctx, at)
+		if !tw.retried(at, generation, err) && atomic.CompareAndSwapInt32(&tw.state, INIT, EXPIRED) {
+			if tw.afterRun != nil {
+				tw.afterRun(tw)
+			}
+			tw.complete(err)
+		}
+		return
 	}
 	if atomic.CompareAndSwapInt32(&tw.state, INIT, EXPIRED) {
-		logger.Printf("Executing task %s at %d", tw.name, time.Now().UnixMilli())
-		tw.runnable()
+		err := tw.call(ctx, at)
 		if tw.afterRun != nil {
 			tw.afterRun(tw)
 		}
-	} else {
-		logger.Printf("Task %s skipped: state=%d", tw.name, tw.state)
+		tw.complete(err)
+	}
+}
+
+// call runs the runnable, a panic is recovered as a PanicError
+func (tw *TimeWork) call(ctx context.Context, at int64) (err error) {
+	var finish func(err error)
+	if tw.track != nil {
+		finish = tw.track(tw.name, at)
 	}
+	defer func() {
+		if r := recover(); r != nil {
+			err = &PanicError{Value: r, Stack: debug.Stack()}
+		}
+		if finish != nil {
+			finish(err)
+		}
+	}()
+	return tw.runnable(ctx)
+}
+
+// at returns the time of the next occurrence
+func (tw *TimeWork) at() int64 {
+	return atomic.LoadInt64(&tw.time)
+}
+
+func (tw *TimeWork) setTime(time int64) {
+	atomic.StoreInt64(&tw.time, time)
+}
+
+func (tw *TimeWork) currentGeneration() uint64 {
+	return atomic.LoadUint64(&tw.generation)
+}
+
+// retried returns true if the failed occurrence is retried
+func (tw *TimeWork) retried(at int64, generation uint64, err error) bool {
+	return tw.backoff != nil && tw.backoff(tw, at, generation, err)
 }
 
 func (tw *TimeWork) IsExpired() bool {
@@ -228,10 +399,10 @@ func (tw *TimeWork) IsCancelled() bool {
 
 func (tw *TimeWork) Cancel() bool {
 	if atomic.CompareAndSwapInt32(&tw.state, INIT, CANCELLED) {
-		logger.Printf("Cancelled task %s", tw.name)
 		if tw.afterCancel != nil {
 			tw.afterCancel(tw)
 		}
+		tw.complete(ErrCancelled)
 		return true
 	}
 	return false
@@ -249,16 +420,47 @@ type TimeScheduler struct {
 	prefix        string
 	workerThreads int
 	timeWheel     *TimeWheel
-	flying        chan *TimeWork
-	working       chan *TimeWork
-	cancels       chan *TimeWork
-	tasks         int64
-	started       int32
-	ctx           context.Context
-	cancelFunc    context.CancelFunc
-}
-
-func NewTimeScheduler(prefix string, tickTime int64, ticks, workerThreads int) *TimeScheduler {
+	inbox         inbox
+	working       chan occurrence
+	// queueCapacity bounds the inbox for TryAdd, runQueueCapacity is the capacity of working
+	queueCapacity    int
+	runQueueCapacity int
+	tasks            int64
+	// state is new, started or stopped, stateMu orders the additions with Stop
+	state        int32
+	stateMu      sync.RWMutex
+	stopCh       chan struct{}
+	haltCh       chan struct{}
+	queueDone    chan struct{}
+	workers      sync.WaitGroup
+	runDueOnStop bool
+	initOnce     sync.Once
+	loaded       []Job
+	abandoned    []string
+	registry     map[string]*TimeWork
+	registryMu   sync.RWMutex
+	duplicates   DuplicatePolicy
+	paused       int32
+	held         []*TimeWork
+	wake         chan struct{}
+	inflight     map[uint64]string
+	runs         uint64
+	inflightMu   sync.Mutex
+	ctx          context.Context
+	cancelFunc   context.CancelFunc
+	// OnError receives the errors returned by the tasks and the recovered panics, set it before Start
+	OnError ErrorHook
+	// OnPanic receives the recovered panics, set it before Start
+	OnPanic  ErrorHook
+	clock    clock.Clock
+	logger   *log.Logger
+	metrics  metrics
+	store    JobStore
+	handlers map[string]JobHandler
+	mu       sync.RWMutex
+}
+
+func NewTimeScheduler(prefix string, tickTime int64, ticks, workerThreads int, opts ...SchedulerOption) *TimeScheduler {
 	if tickTime <= 0 || ticks <= 0 || workerThreads <= 0 {
 		panic("invalid parameters")
 	}
@@ -266,50 +468,65 @@ func NewTimeScheduler(prefix string, tickTime int64, ticks, workerThreads int) *
 		prefix = "timer"
 	}
 	ctx, cancel := context.WithCancel(context.Background())
-	return &TimeScheduler{
-		prefix:        prefix,
-		workerThreads: workerThreads,
-		timeWheel:     newTimeWheel(tickTime, ticks, time.Now().UnixMilli()),
-		flying:        make(chan *TimeWork, 1000),
-		working:       make(chan *TimeWork, 1000),
-		cancels:       make(chan *TimeWork, 1000),
-		ctx:           ctx,
-		cancelFunc:    cancel,
-	}
-}
-
-func (ts *TimeScheduler) Start() {
-	if atomic.CompareAndSwapInt32(&ts.started, 0, 1) {
-		logger.Println("Starting scheduler")
-		go ts.processQueue()
-		for i := 0; i < ts.workerThreads; i++ {
-			go ts.processWorking()
-		}
+	ts := &TimeScheduler{
+		prefix:           prefix,
+		workerThreads:    workerThreads,
+		queueCapacity:    DefaultQueueCapacity,
+		runQueueCapacity: DefaultQueueCapacity,
+		stopCh:           make(chan struct{}),
+		haltCh:           make(chan struct{}),
+		queueDone:        make(chan struct{}),
+		inflight:         make(map[uint64]string),
+		registry:         make(map[string]*TimeWork),
+		duplicates:       DuplicateAllow,
+		wake:             make(chan struct{}, 1),
+		ctx:              ctx,
+		cancelFunc:       cancel,
+		handlers:         make(map[string]JobHandler),
 	}
+	for _, opt := range opts {
+		opt(ts)
+	}
+	ts.working = make(chan occurrence, ts.runQueueCapacity)
+	ts.clock = clock.OrReal(ts.clock)
+	ts.metrics = newMetrics()
+	ts.timeWheel = newTimeWheel(tickTime, ticks, ts.now())
+	return ts
+}
+
+// now returns the time of the clock in milliseconds
+func (ts *TimeScheduler) now() int64 {
+	return ts.clock.Now().UnixMilli()
 }
 
-func (ts *TimeScheduler) Close() {
-	if atomic.CompareAndSwapInt32(&ts.started, 1, 0) {
-		logger.Println("Closing scheduler")
-		ts.cancel()
+// completion returns the time of the clock in milliseconds rounded up, so that a delay counted from the
+// completion of a run is never shortened
+func (ts *TimeScheduler) completion() int64 {
+	now := ts.clock.Now()
+	completion := now.UnixMilli()
+	if now.UnixNano() > completion*int64(time.Millisecond) {
+		completion++
 	}
+	return completion
 }
 
+// Add runs the runnable at the time in milliseconds. The addition is never refused, the additions waiting
+// for the queue are reported by Stats as Backlog and TryAdd bounds them.
 func (ts *TimeScheduler) Add(name string, time int64, runnable func()) Timeout {
 	if runnable == nil {
 		return nil
 	}
-	tw := newTimeWork(name, time, runnable, ts.afterRun, ts.afterCancel)
+	tw := newTimeWork(name, time, runnableFunc(runnable), ts.afterRun, ts.afterCancel)
 	return ts.add(tw)
 }
 
+// Delay runs the runnable after the delay in milliseconds, the addition is never refused like Add
 func (ts *TimeScheduler) Delay(name string, delay int64, runnable func()) Timeout {
 	if runnable == nil {
 		return nil
 	}
-	t := time.Now().UnixMilli() + delay
-	logger.Printf("Scheduling delay task %s: delay=%d, scheduled=%d", name, delay, t)
-	tw := newTimeWork(name, t, runnable, ts.afterRun, ts.afterCancel)
+	t := ts.now() + delay
+	tw := newTimeWork(name, t, runnableFunc(runnable), ts.afterRun, ts.afterCancel)
 	return ts.add(tw)
 }
 
@@ -319,103 +536,235 @@ func (ts *TimeScheduler) AddTask(task TimeTask) Timeout {
 	}
 	t := task.GetTime()
 	if _, ok := task.(*DelayTask); ok {
-		t = time.Now().UnixMilli() + t
+		t = ts.now() + t
 	}
-	tw := newTimeWork(task.GetName(), t, task.Run, ts.afterRun, ts.afterCancel)
+	tw := newTimeWork(task.GetName(), t, runnableFunc(task.Run), ts.afterRun, ts.afterCancel)
 	return ts.add(tw)
 }
 
-func (ts *TimeScheduler) add(timeWork *TimeWork) Timeout {
-	atomic.AddInt64(&ts.tasks, 1)
-	ts.flying <- timeWork
-	logger.Printf("Task %s queued for scheduling", timeWork.name)
+func (ts *TimeScheduler) add(timeWork *TimeWork) *TimeWork {
+	_ = ts.submit(timeWork, false)
 	return timeWork
 }
 
+// submit queues the task, the bounded submissions fail when the inbox is full
+func (ts *TimeScheduler) submit(timeWork *TimeWork, bounded bool) error {
+	ts.stateMu.RLock()
+	defer ts.stateMu.RUnlock()
+	if ts.state == schedulerStopped {
+		ts.debug("Task refused, the scheduler is stopped", log.String("task", timeWork.name))
+		timeWork.complete(ErrStopped)
+		return ErrStopped
+	}
+	if err := ts.register(timeWork); err != nil {
+		return ts.refuse(timeWork, err)
+	}
+	atomic.AddInt64(&ts.tasks, 1)
+	timeWork.rearm = ts.rearm
+	timeWork.wakeup = ts.nudge
+	if timeWork.retry != nil {
+		timeWork.backoff = ts.retry
+		// a recurring task is retried before its next occurrence
+		timeWork.serial = timeWork.serial || timeWork.next != nil
+	}
+	timeWork.track = ts.track
+	ts.debug("Task added", log.String("task", timeWork.name), log.Int64("time", timeWork.at()))
+	if !ts.postAddition(occurrence{work: timeWork}, bounded) {
+		ts.unregister(timeWork)
+		atomic.AddInt64(&ts.tasks, -1)
+		return ts.refuse(timeWork, ErrQueueFull)
+	}
+	return nil
+}
+
+// rearm schedules the next occurrence of a serial task after the previous one completed, unless the
+// task was rescheduled meanwhile
+func (ts *TimeScheduler) rearm(tw *TimeWork, generation uint64, err error) {
+	if tw.currentGeneration() != generation {
+		return
+	}
+	next := tw.following(tw.at(), ts.completion())
+	if next < 0 {
+		if atomic.CompareAndSwapInt32(&tw.state, INIT, EXPIRED) {
+			if tw.afterRun != nil {
+				tw.afterRun(tw)
+			}
+			tw.complete(err)
+		}
+		return
+	}
+	ts.requeue(tw, generation, next)
+}
+
+// requeue arms the task again at the time, unless it was rescheduled meanwhile
+func (ts *TimeScheduler) requeue(tw *TimeWork, generation uint64, time int64) {
+	tw.mu.Lock()
+	if tw.currentGeneration() != generation {
+		tw.mu.Unlock()
+		return
+	}
+	tw.setTime(time)
+	tw.mu.Unlock()
+	ts.stateMu.RLock()
+	defer ts.stateMu.RUnlock()
+	if ts.state == schedulerStopped {
+		ts.abandon(tw)
+		return
+	}
+	ts.post(occurrence{work: tw, generation: generation})
+}
+
 func (ts *TimeScheduler) afterRun(tw *TimeWork) {
+	ts.unregister(tw)
 	atomic.AddInt64(&ts.tasks, -1)
 }
 
 func (ts *TimeScheduler) afterCancel(tw *TimeWork) {
+	ts.debug("Task cancelled", log.String("task", tw.name))
+	ts.unregister(tw)
 	atomic.AddInt64(&ts.tasks, -1)
-	ts.cancels <- tw
+	atomic.AddUint64(&ts.metrics.cancelled, 1)
+	ts.postCancel(tw)
 }
 
-func (ts *TimeScheduler) cancel() {
-	for {
-		select {
-		case tw := <-ts.cancels:
-			logger.Printf("Processing cancellation for task %s", tw.name)
-			tw.remove()
-		default:
-			return
-		}
+// arm schedules the task of the occurrence, unless the task was rescheduled since the occurrence was queued
+func (ts *TimeScheduler) arm(o occurrence, currentTime int64) {
+	if o.generation == o.work.currentGeneration() {
+		ts.schedule(o.work, currentTime)
 	}
 }
 
-func (ts *TimeScheduler) supply() {
-	for i := 0; i < 100000; i++ {
-		select {
-		case tw := <-ts.flying:
-			if !tw.IsCancelled() {
-				if !ts.timeWheel.add(tw) {
-					logger.Printf("Task %s is due, adding to working queue", tw.name)
-					ts.working <- tw
-				}
-			} else {
-				logger.Printf("Task %s already cancelled, skipping", tw.name)
-			}
-		default:
-			return
-		}
+func (ts *TimeScheduler) schedule(tw *TimeWork, currentTime int64) {
+	if tw.IsCancelled() {
+		ts.debug("Task already cancelled", log.String("task", tw.name))
+	} else if ts.timeWheel.add(tw, currentTime) {
+		ts.debug("Task scheduled", log.String("task", tw.name), log.Int64("time", tw.at()),
+			log.Int64("slot", tw.timeSlot.expiration))
+	} else {
+		ts.dispatch(tw, currentTime)
 	}
 }
 
+// dispatch queues the due occurrence of the task, and arms the next occurrence of a recurring task
+func (ts *TimeScheduler) dispatch(tw *TimeWork, currentTime int64) {
+	at := tw.at()
+	generation := tw.currentGeneration()
+	ts.debug("Task due", log.String("task", tw.name), log.Int64("time", at),
+		log.Duration("lateness", time.Duration(currentTime-at)*time.Millisecond))
+	if ts.hold(tw) {
+		return
+	}
+	if tw.misfired(currentTime-at, ts.timeWheel.tickTime) {
+		ts.misfire(tw, at, currentTime)
+		return
+	}
+	if tw.next == nil {
+		ts.work(occurrence{work: tw, time: at, last: true, generation: generation})
+		return
+	}
+	at = tw.coalesce(at, currentTime)
+	tw.setTime(at)
+	if tw.serial {
+		ts.work(occurrence{work: tw, time: at, generation: generation})
+		return
+	}
+	next := tw.following(at, currentTime)
+	if next >= 0 {
+		tw.setTime(next)
+	}
+	ts.work(occurrence{work: tw, time: at, last: next < 0, generation: generation})
+	if next >= 0 && next <= currentTime {
+		// the missed occurrences are dispatched one per pass of the queue
+		ts.post(occurrence{work: tw, generation: generation})
+	} else if next >= 0 {
+		ts.schedule(tw, currentTime)
+	}
+}
+
+// processQueue owns the time wheels. It sleeps until the earliest slot expires, or a task is added or
+// cancelled.
 func (ts *TimeScheduler) processQueue() {
+	defer close(ts.queueDone)
+	timer := ts.clock.NewTimer(time.Hour)
+	defer timer.Stop()
 	for {
+		currentTime := ts.now()
+		ts.timeWheel.expire(currentTime, func(tw *TimeWork) {
+			ts.dispatch(tw, currentTime)
+		})
+		ts.receive(currentTime)
+		ts.release(currentTime)
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		var wakeup <-chan time.Time
+		if expiration, ok := ts.timeWheel.nextExpiration(); ok {
+			// the clock may have moved since the expiration was computed, then the wheels are expired again
+			now := ts.now()
+			if expiration <= now {
+				continue
+			}
+			timer.Reset(time.Duration(expiration-now) * time.Millisecond)
+			if ts.now() != now {
+				continue
+			}
+			wakeup = timer.C()
+		}
 		select {
 		case <-ts.ctx.Done():
-			logger.Println("Queue processor shutting down")
 			return
-		case slot := <-ts.timeWheel.queue:
-			currentTime := time.Now().UnixMilli()
-			if slot.expiration <= currentTime {
-				logger.Printf("Processing slot with expiration %d at %d", slot.expiration, currentTime)
-				ts.cancel()
-				ts.supply()
-				slot.flush(func(tw *TimeWork) {
-					if !tw.IsCancelled() {
-						ts.working <- tw
-					} else {
-						logger.Printf("Skipping cancelled task %s in slot", tw.name)
-					}
+		case <-ts.stopCh:
+			if ts.runDueOnStop {
+				currentTime = ts.now()
+				ts.receive(currentTime)
+				ts.timeWheel.expire(currentTime, func(tw *TimeWork) {
+					ts.dispatch(tw, currentTime)
 				})
-				ts.timeWheel.advance(currentTime)
-			} else {
-				// Requeue slot if not yet expired
-				select {
-				case ts.timeWheel.queue <- slot:
-				default:
-				}
 			}
-		default:
-			ts.cancel()
-			ts.supply()
-			time.Sleep(time.Duration(ts.timeWheel.tickTime) * time.Millisecond)
-			ts.timeWheel.advance(time.Now().UnixMilli())
+			return
+		case <-wakeup:
+		case <-ts.wake:
 		}
 	}
 }
 
+// work queues the occurrence for the workers, unless the scheduler is halted
+func (ts *TimeScheduler) work(o occurrence) {
+	select {
+	case ts.working <- o:
+	case <-ts.ctx.Done():
+		ts.abandon(o.work)
+	}
+}
+
+// processWorking runs the due occurrences until the queue is closed, or the scheduler is halted
 func (ts *TimeScheduler) processWorking() {
+	defer ts.workers.Done()
 	for {
 		select {
 		case <-ts.ctx.Done():
-			logger.Println("Worker shutting down")
 			return
-		case tw := <-ts.working:
-			if tw != nil && !tw.IsCancelled() {
-				tw.Run()
+		case <-ts.haltCh:
+			return
+		case o, ok := <-ts.working:
+			if !ok {
+				return
+			}
+			select {
+			case <-ts.haltCh:
+				ts.abandon(o.work)
+				return
+			case <-ts.ctx.Done():
+				ts.abandon(o.work)
+				return
+			default:
+			}
+			if !o.work.IsCancelled() && o.generation == o.work.currentGeneration() {
+				o.work.run(ts.ctx, o.time, o.generation, o.last)
 			}
 		}
 	}
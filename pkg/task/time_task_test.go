@@ -1,32 +1,125 @@
 package task
 
 import (
+	"context"
 	"fmt"
+	"math/rand"
 	"testing"
 	"time"
+
+	"github.com/meshware/suit-kit-golang/pkg/clock"
 )
 
 func TestTimeScheduler(t *testing.T) {
-	scheduler := NewTimeScheduler("test-timer", 100, 60, 2)
-	scheduler.Start()
+	fakeClock := clock.NewFakeClock(time.Time{})
+	scheduler := NewTimeScheduler("test-timer", 100, 60, 2, WithClock(fakeClock))
+	scheduler.Start(context.Background())
 	defer scheduler.Close()
 
+	executed := make(chan string, 3)
+	record := func(name string) func() {
+		return func() {
+			executed <- fmt.Sprint(name, "@", fakeClock.Since(time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)))
+		}
+	}
 	// Schedule a task to run after 500ms
-	scheduler.Delay("task1", 500, func() {
-		fmt.Println("Task1 executed at", time.Now())
-	})
-
+	scheduler.Delay("task1", 500, record("task1"))
 	// Schedule a task with absolute time
-	absTime := time.Now().UnixMilli() + 10000
-	scheduler.Add("task2", absTime, func() {
-		fmt.Println("Task2 executed at", time.Now())
-	})
-
+	scheduler.Add("task2", fakeClock.Now().UnixMilli()+10000, record("task2"))
 	// Schedule a DelayTask
-	task := NewDelayTask("task3", 1500, func() {
-		fmt.Println("Task3 executed at", time.Now())
-	})
-	scheduler.AddTask(task)
+	scheduler.AddTask(NewDelayTask("task3", 1500, record("task3")))
+
+	expected := []string{"task1@500ms", "task3@1.5s", "task2@10s"}
+	for i, step := range []time.Duration{500 * time.Millisecond, time.Second, 8500 * time.Millisecond} {
+		fakeClock.Advance(step)
+		select {
+		case actualValue := <-executed:
+			if actualValue != expected[i] {
+				t.Errorf("Got %v expected %v", actualValue, expected[i])
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%v did not run", expected[i])
+		}
+	}
+}
+
+func TestTimeSchedulerVirtualTime(t *testing.T) {
+	fakeClock := clock.NewFakeClock(time.Time{})
+	scheduler := NewTimeScheduler("virtual", 10, 100, 4, WithClock(fakeClock))
+	scheduler.Start(context.Background())
+	defer scheduler.Close()
+
+	start := fakeClock.Now().UnixMilli()
+	random := rand.New(rand.NewSource(1))
+	futures := make([]Future, 5000)
+	times := make([]int64, len(futures))
+	runs := make([]int64, len(futures))
+	for i := range futures {
+		i := i
+		times[i] = start + random.Int63n(time.Hour.Milliseconds())
+		futures[i] = scheduler.AddFunc(fmt.Sprint("task-", i), times[i], func(ctx context.Context) error {
+			runs[i] = fakeClock.Now().UnixMilli()
+			return nil
+		})
+	}
+	step := 10 * time.Second
+	for now := start; now < start+time.Hour.Milliseconds(); {
+		fakeClock.Advance(step)
+		now += step.Milliseconds()
+		for i, future := range futures {
+			if times[i] > now {
+				continue
+			}
+			select {
+			case <-future.Done():
+			case <-time.After(time.Second):
+				t.Fatalf("task-%d due at %d did not run at %d", i, times[i], now)
+			}
+		}
+	}
+	for i := range futures {
+		if lateness := runs[i] - times[i]; lateness < 0 || lateness > step.Milliseconds() {
+			t.Errorf("Got lateness %v of task-%d expected within %v", lateness, i, step)
+		}
+	}
+}
+
+func TestTimeWheelOverflow(t *testing.T) {
+	start := int64(1_000_000)
+	wheel := newTimeWheel(10, 10, start)
+	runs := make(map[string]int64)
+	delays := map[string]int64{"tick": 5, "wheel": 95, "second": 150, "minute": 5_432, "hour": 3_600_000}
+	for name, delay := range delays {
+		if !wheel.add(newTimeWork(name, start+delay, nil, nil, nil), start) {
+			t.Errorf("Got %v expected %v", false, true)
+		}
+	}
+	if wheel.overflow == nil || wheel.overflow.overflow == nil {
+		t.Fatal("expected overflow wheels")
+	}
+	expired := newTimeWork("expired", start, nil, nil, nil)
+	if wheel.add(expired, start) {
+		t.Errorf("Got %v expected %v", true, false)
+	}
 
-	time.Sleep(20 * time.Second)
+	steps := 0
+	for now := start; len(runs) < len(delays); steps++ {
+		next, ok := wheel.nextExpiration()
+		if !ok {
+			t.Fatal("expected a queued slot")
+		}
+		now = next
+		wheel.expire(now, func(tw *TimeWork) {
+			runs[tw.name] = now
+		})
+	}
+	for name, delay := range delays {
+		if lateness := runs[name] - (start + delay); lateness < 0 || lateness >= 10 {
+			t.Errorf("Got lateness %v of %v expected within a tick", lateness, name)
+		}
+	}
+	// the hour cascades through the levels instead of waking up every tick
+	if steps > 30 {
+		t.Errorf("Got %v expected at most %v wakeups", steps, 30)
+	}
 }